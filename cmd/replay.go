@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kaato137/quickrest/internal/pkg/recording"
+	"github.com/kaato137/quickrest/internal/pkg/replay"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replayTarget  string
+	replayCompare bool
+	replayTimeout time.Duration
+	replayHeaders []string
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay --target URL [records...]",
+	Short: "Send recorded requests to a real service",
+	Long: `Replay reads the request logs written for routes with 'record: true' and
+sends every request to the target. With --compare, the answers are checked
+against the recorded responses and the differences are printed.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			args = []string{"records"}
+		}
+
+		rp, err := replay.New(replayTarget)
+		if err != nil {
+			return err
+		}
+		header, err := parseHeaders(replayHeaders)
+		if err != nil {
+			return err
+		}
+		rp.WithCompare(replayCompare).WithHeader(header)
+
+		files, err := recordFiles(args)
+		if err != nil {
+			return err
+		}
+
+		var total, failed, errored, skipped int
+		out := cmd.OutOrStdout()
+		for _, file := range files {
+			// Logs written before responses were recorded, or damaged
+			// ones, are skipped from the first entry that cannot be read.
+			entries, err := readRecords(file)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "WARN  skipping the rest of %s: %v\n", file, err)
+			}
+
+			for _, entry := range entries {
+				ctx, cancel := context.WithCancel(cmd.Context())
+				if replayTimeout > 0 {
+					ctx, cancel = context.WithTimeout(cmd.Context(), replayTimeout)
+				}
+				result := rp.Replay(ctx, entry)
+				cancel()
+
+				total++
+				switch {
+				case errors.Is(result.Err, replay.ErrNotHTTP), errors.Is(result.Err, replay.ErrNeedsCredentials):
+					skipped++
+				case result.Err != nil:
+					errored++
+				case !result.Passed():
+					failed++
+				}
+				printResult(out, result)
+			}
+		}
+
+		if replayCompare {
+			fmt.Fprintf(out, "\n%d replayed: %d passed, %d failed, %d errors, %d skipped\n",
+				total, total-failed-errored-skipped, failed, errored, skipped)
+		} else {
+			fmt.Fprintf(out, "\n%d replayed: %d sent, %d errors, %d skipped\n",
+				total, total-errored-skipped, errored, skipped)
+		}
+
+		if failed > 0 || errored > 0 {
+			return fmt.Errorf("%d of %d requests did not replay cleanly", failed+errored, total)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringVarP(&replayTarget, "target", "t", "", "base URL of the service to send requests to")
+	replayCmd.Flags().BoolVar(&replayCompare, "compare", false, "compare answers with the recorded responses")
+	replayCmd.Flags().DurationVar(&replayTimeout, "timeout", 30*time.Second, "timeout of a single request, 0 for none")
+	replayCmd.Flags().StringArrayVarP(&replayHeaders, "header", "H", nil, `header to set on every request, such as "Authorization: Bearer ...", can be repeated`)
+	_ = replayCmd.MarkFlagRequired("target")
+
+	rootCmd.AddCommand(replayCmd)
+}
+
+func printResult(w io.Writer, result replay.Result) {
+	switch {
+	case errors.Is(result.Err, replay.ErrNotHTTP):
+		fmt.Fprintf(w, "SKIP  %s: %v\n", result.URL, result.Err)
+	case errors.Is(result.Err, replay.ErrNeedsCredentials):
+		fmt.Fprintf(w, "SKIP  %s %s %d: %v, pass it with --header\n", result.Method, result.URL, result.Status, result.Err)
+	case result.Err != nil:
+		fmt.Fprintf(w, "ERROR %s %s: %v\n", result.Method, result.URL, result.Err)
+	case !result.Compared:
+		fmt.Fprintf(w, "SENT  %s %s %d\n", result.Method, result.URL, result.Status)
+	case result.Passed():
+		fmt.Fprintf(w, "PASS  %s %s %d\n", result.Method, result.URL, result.Status)
+	default:
+		fmt.Fprintf(w, "FAIL  %s %s %d\n", result.Method, result.URL, result.Status)
+		for _, diff := range result.Diffs {
+			fmt.Fprintf(w, "      %s\n", diff)
+		}
+	}
+}
+
+// parseHeaders reads "Name: value" flags.
+func parseHeaders(values []string) (http.Header, error) {
+	header := make(http.Header, len(values))
+	for _, value := range values {
+		name, v, ok := strings.Cut(value, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("header %q: want \"Name: value\"", value)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(v))
+	}
+
+	return header, nil
+}
+
+// recordFiles expands directories into the logs they contain. WalkDir goes
+// in name order, which is date order for the logs of one route.
+func recordFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(p) == ".log" {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+func readRecords(path string) ([]recording.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// The entries before a malformed one are still returned.
+	return recording.Read(f)
+}
@@ -10,7 +10,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var configPath string
+var (
+	configPath string
+	seed       int64
+
+	logFormat string
+	logLevel  string
+	logFile   string
+	accessLog string
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "quickrest",
@@ -25,7 +33,27 @@ var rootCmd = &cobra.Command{
 			return err
 		}
 
-		server, err := internal.NewServerFromConfig(cfg)
+		// Flags win over the file, also after it was reloaded.
+		override := func(cfg *conf.Config) {
+			if cmd.Flags().Changed("seed") {
+				cfg.Seed = &seed
+			}
+
+			if cmd.Flags().Changed("log-format") {
+				cfg.Log.Format = logFormat
+			}
+			if cmd.Flags().Changed("log-level") {
+				cfg.Log.Level = logLevel
+			}
+			if cmd.Flags().Changed("log-file") {
+				cfg.Log.File = logFile
+			}
+			if cmd.Flags().Changed("access-log") {
+				cfg.Log.Access = accessLog
+			}
+		}
+
+		server, err := internal.NewServerFromConfig(cfg, override)
 		cobra.CheckErr(err)
 
 		defer server.Close()
@@ -39,6 +67,11 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "path to a configuration file")
+	rootCmd.Flags().Int64Var(&seed, "seed", 0, "seed for all randomness, makes responses reproducible")
+	rootCmd.Flags().StringVar(&logFormat, "log-format", conf.LogFormatText, "log format: text, json or logfmt")
+	rootCmd.Flags().StringVar(&logLevel, "log-level", "info", "minimum log level: debug, info, warn or error")
+	rootCmd.Flags().StringVar(&logFile, "log-file", "", "write logs to a file instead of stderr")
+	rootCmd.Flags().StringVar(&accessLog, "access-log", "", "log requests in an access log format: combined")
 
 	rootCmd.AddCommand(generateDefaultConfigCmd)
 }
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// AdminHandler serves the admin API, which controls the mock at runtime.
+func (s *Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("GET /metrics", s.metrics.Handler())
+
+	mux.HandleFunc("GET /clock", s.handleGetClock)
+	mux.HandleFunc("PUT /clock", s.handleSetClock)
+	mux.HandleFunc("POST /clock/advance", s.handleAdvanceClock)
+
+	mux.HandleFunc("GET /scenarios", s.handleGetScenarios)
+	mux.HandleFunc("PUT /scenarios/{name}", s.handleSetScenario)
+	mux.HandleFunc("POST /scenarios/reset", s.handleResetScenarios)
+	mux.HandleFunc("POST /scenarios/{name}/reset", s.handleResetScenario)
+
+	mux.HandleFunc("GET /{$}", s.handleDashboard)
+	mux.HandleFunc("GET /routes", s.handleGetRoutes)
+	mux.HandleFunc("PUT /routes/enabled", s.handleSetRouteEnabled)
+	mux.HandleFunc("PUT /routes/response", s.handleSetRouteResponse)
+	mux.HandleFunc("GET /reload", s.handleGetReload)
+	mux.HandleFunc("GET /activity", s.handleActivity)
+
+	return mux
+}
+
+func (s *Server) setupAdmin() error {
+	if s.cfg.AdminAddress == "" {
+		return nil
+	}
+
+	lis, err := net.Listen("tcp", s.cfg.AdminAddress)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	srv := &http.Server{Handler: s.AdminHandler()}
+
+	go func() {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Admin server stopped", "err", err)
+		}
+	}()
+	s.appendCloser(func() { _ = srv.Close() })
+
+	s.logger.Info("Admin listen on", "addr", s.cfg.AdminAddress)
+
+	return nil
+}
+
+type clockState struct {
+	Now    time.Time `json:"now"`
+	Frozen bool      `json:"frozen"`
+}
+
+func (s *Server) handleGetClock(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, http.StatusOK, clockState{Now: s.clock.Now(), Frozen: s.clock.Frozen()})
+}
+
+func (s *Server) handleSetClock(rw http.ResponseWriter, r *http.Request) {
+	var req clockState
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	s.clock.Set(req.Now, req.Frozen)
+	s.logger.Info("Clock set", "now", s.clock.Now(), "frozen", req.Frozen)
+
+	s.handleGetClock(rw, r)
+}
+
+func (s *Server) handleAdvanceClock(rw http.ResponseWriter, r *http.Request) {
+	var req struct {
+		By string `json:"by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	d, err := time.ParseDuration(req.By)
+	if err != nil {
+		writeJSONError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	s.clock.Advance(d)
+	s.logger.Info("Clock advanced", "by", d)
+
+	s.handleGetClock(rw, r)
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(v)
+}
+
+func writeJSONError(rw http.ResponseWriter, status int, err error) {
+	writeJSON(rw, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,315 @@
+package internal
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/jwks"
+)
+
+const (
+	jwksFetchTimeout = 10 * time.Second
+	jwksOIDC         = "oidc"
+)
+
+var (
+	errNoSecret = errors.New("token is signed with HMAC but no secret is configured")
+	errNoJWKS   = errors.New("token is signed with a public key but no jwks is configured")
+)
+
+type claimsKey struct{}
+
+// authenticator checks the credentials a route asks for.
+type authenticator struct {
+	cfg    *conf.AuthConfig
+	secret []byte
+	keys   *jwks.Set
+	parser *jwt.Parser
+}
+
+// authError is a rejected request. Without a status it is a 401.
+type authError struct {
+	status      int
+	code        string
+	description string
+}
+
+func (s *Server) newAuthenticator(cfg *conf.AuthConfig) (*authenticator, error) {
+	a := &authenticator{cfg: cfg}
+	if cfg.JWT == nil {
+		return a, nil
+	}
+
+	var methods []string
+	if cfg.JWT.Secret != "" {
+		a.secret = []byte(cfg.JWT.Secret)
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+
+	if cfg.JWT.JWKS != "" {
+		keys, err := s.loadJWKS(cfg.JWT.JWKS)
+		if err != nil {
+			return nil, fmt.Errorf("load jwks: %w", err)
+		}
+		a.keys = keys
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.JWT.Leeway),
+		jwt.WithTimeFunc(s.clock.Now),
+	}
+	if cfg.JWT.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWT.Issuer))
+	}
+	if cfg.JWT.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWT.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+// loadJWKS reads a key set from a URL or from a file next to the config.
+// "oidc" stands for the keys of the built-in OIDC provider.
+func (s *Server) loadJWKS(source string) (*jwks.Set, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if source == jwksOIDC {
+		if s.oidc == nil {
+			return nil, errOIDCNotConfigured
+		}
+		data, err = json.Marshal(s.oidc.jwks())
+	} else if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		data, err = fetchJWKS(source)
+	} else {
+		data, err = os.ReadFile(s.cfg.ResolvePath(source))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return jwks.Parse(data)
+}
+
+func fetchJWKS(url string) ([]byte, error) {
+	client := http.Client{Timeout: jwksFetchTimeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: unexpected status %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// protect lets only authenticated requests through to the responder,
+// which finds the claims in the request context.
+func (a *authenticator) protect(respond responder) responder {
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		claims, authErr := a.authenticate(r)
+		if authErr != nil {
+			a.reject(rw, authErr)
+			return nil
+		}
+
+		return respond(rw, withClaims(r, claims))
+	}
+}
+
+// authenticate returns the claims of the caller. Basic auth yields the
+// user name as "sub".
+func (a *authenticator) authenticate(r *http.Request) (map[string]any, *authError) {
+	switch a.cfg.Type {
+	case conf.AuthTypeBasic:
+		return a.basic(r)
+	case conf.AuthTypeAPIKey:
+		return a.apiKey(r)
+	case conf.AuthTypeBearer:
+		return a.bearer(r)
+	default:
+		return map[string]any{}, nil
+	}
+}
+
+func (a *authenticator) basic(r *http.Request) (map[string]any, *authError) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, &authError{code: "unauthorized", description: "missing basic credentials"}
+	}
+
+	expected, known := a.cfg.Users[user]
+	if !known || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+		return nil, &authError{code: "unauthorized", description: "invalid user name or password"}
+	}
+
+	return map[string]any{"sub": user}, nil
+}
+
+func (a *authenticator) apiKey(r *http.Request) (map[string]any, *authError) {
+	var key string
+	if a.cfg.Header != "" {
+		key = r.Header.Get(a.cfg.Header)
+	}
+	if key == "" && a.cfg.Query != "" {
+		key = r.URL.Query().Get(a.cfg.Query)
+	}
+
+	if key == "" {
+		return nil, &authError{code: "unauthorized", description: "missing api key"}
+	}
+
+	if !containsSecret(a.cfg.Keys, key) {
+		return nil, &authError{code: "unauthorized", description: "invalid api key"}
+	}
+
+	return map[string]any{}, nil
+}
+
+func (a *authenticator) bearer(r *http.Request) (map[string]any, *authError) {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, &authError{description: "missing bearer token"}
+	}
+
+	if containsSecret(a.cfg.Tokens, token) {
+		return map[string]any{}, nil
+	}
+
+	if a.parser == nil {
+		return nil, &authError{code: "invalid_token", description: "unknown token"}
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, a.key); err != nil {
+		return nil, &authError{code: "invalid_token", description: err.Error()}
+	}
+
+	granted := tokenScopes(claims)
+	for _, scope := range a.cfg.JWT.Scopes {
+		if !granted[scope] {
+			return nil, &authError{
+				status:      http.StatusForbidden,
+				code:        "insufficient_scope",
+				description: fmt.Sprintf("missing scope %q", scope),
+			}
+		}
+	}
+
+	return claims, nil
+}
+
+func (a *authenticator) key(token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if a.secret == nil {
+			return nil, errNoSecret
+		}
+		return a.secret, nil
+	}
+
+	if a.keys == nil {
+		return nil, errNoJWKS
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	return a.keys.Key(kid)
+}
+
+// reject answers like an OAuth 2.0 resource server would (RFC 6750).
+func (a *authenticator) reject(rw http.ResponseWriter, authErr *authError) {
+	status := authErr.status
+	if status == 0 {
+		status = http.StatusUnauthorized
+	}
+
+	switch a.cfg.Type {
+	case conf.AuthTypeBasic:
+		rw.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", a.cfg.Realm))
+	case conf.AuthTypeBearer:
+		challenge := fmt.Sprintf("Bearer realm=%q", a.cfg.Realm)
+		if authErr.code != "" {
+			challenge += fmt.Sprintf(", error=%q, error_description=%q", authErr.code, authErr.description)
+		}
+		if authErr.code == "insufficient_scope" {
+			challenge += fmt.Sprintf(", scope=%q", strings.Join(a.cfg.JWT.Scopes, " "))
+		}
+		rw.Header().Set("WWW-Authenticate", challenge)
+	}
+
+	code := authErr.code
+	if code == "" {
+		code = "unauthorized"
+	}
+
+	writeJSON(rw, status, map[string]string{
+		"error":             code,
+		"error_description": authErr.description,
+	})
+}
+
+// tokenScopes reads the space separated "scope" claim and the "scp"
+// claim, which some providers send as a list.
+func tokenScopes(claims jwt.MapClaims) map[string]bool {
+	granted := make(map[string]bool)
+
+	for _, name := range []string{"scope", "scp"} {
+		switch v := claims[name].(type) {
+		case string:
+			for _, scope := range strings.Fields(v) {
+				granted[scope] = true
+			}
+		case []any:
+			for _, scope := range v {
+				if s, ok := scope.(string); ok {
+					granted[s] = true
+				}
+			}
+		}
+	}
+
+	return granted
+}
+
+func containsSecret(secrets []string, value string) bool {
+	for _, secret := range secrets {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(value)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func withClaims(r *http.Request, claims map[string]any) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims))
+}
+
+// requestClaims returns the claims of the authenticated caller, or an
+// empty map on routes without auth.
+func requestClaims(r *http.Request) map[string]any {
+	if claims, ok := r.Context().Value(claimsKey{}).(map[string]any); ok {
+		return claims
+	}
+
+	return map[string]any{}
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/kaato137/quickrest/internal/conf"
+)
+
+// callback is a parsed CallbackConfig.
+type callback struct {
+	conf.CallbackConfig
+
+	url     *template.Template
+	body    *template.Template
+	headers map[string]*template.Template
+}
+
+// pendingCallback is a callback rendered for one request, ready to send.
+type pendingCallback struct {
+	conf.CallbackConfig
+
+	url     string
+	body    []byte
+	headers map[string]string
+}
+
+func (s *Server) parseCallbacks(route conf.RouteConfig) ([]callback, error) {
+	callbacks := make([]callback, 0, len(route.Callbacks))
+
+	for i, cfg := range route.Callbacks {
+		name := fmt.Sprintf("%s callback %d", route.Path, i)
+		cb := callback{CallbackConfig: cfg, headers: make(map[string]*template.Template)}
+
+		var err error
+		if cb.url, err = s.parseTemplate(name+" url", cfg.URL); err != nil {
+			return nil, fmt.Errorf("callback %d: parse url: %w", i, err)
+		}
+
+		if cb.body, err = s.parseTemplate(name+" body", cfg.Body); err != nil {
+			return nil, fmt.Errorf("callback %d: parse body: %w", i, err)
+		}
+
+		for k, v := range cfg.Headers {
+			if cb.headers[k], err = s.parseTemplate(name+" header "+k, v); err != nil {
+				return nil, fmt.Errorf("callback %d: parse header %q: %w", i, k, err)
+			}
+		}
+
+		callbacks = append(callbacks, cb)
+	}
+
+	return callbacks, nil
+}
+
+// withCallbacks sends the callbacks of a route once it has responded. They
+// are rendered right away, because the request is gone by the time they
+// are sent.
+func (s *Server) withCallbacks(route conf.RouteConfig, callbacks []callback, respond responder) responder {
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		if err := respond(rw, r); err != nil {
+			return err
+		}
+
+		data, err := s.newTemplateData(route, r)
+		if err != nil {
+			return fmt.Errorf("prepare callback data: %w", err)
+		}
+
+		for i, cb := range callbacks {
+			pending, err := cb.render(data)
+			if err != nil {
+				return fmt.Errorf("render callback %d: %w", i, err)
+			}
+
+			go s.sendCallback(route, pending)
+		}
+
+		return nil
+	}
+}
+
+func (cb callback) render(data TemplateData) (pendingCallback, error) {
+	url, err := executeTemplate(cb.url, data)
+	if err != nil {
+		return pendingCallback{}, fmt.Errorf("url: %w", err)
+	}
+
+	body, err := executeTemplate(cb.body, data)
+	if err != nil {
+		return pendingCallback{}, fmt.Errorf("body: %w", err)
+	}
+
+	headers := make(map[string]string, len(cb.headers))
+	for k, tmpl := range cb.headers {
+		value, err := executeTemplate(tmpl, data)
+		if err != nil {
+			return pendingCallback{}, fmt.Errorf("header %q: %w", k, err)
+		}
+		headers[k] = string(value)
+	}
+
+	return pendingCallback{
+		CallbackConfig: cb.CallbackConfig,
+		url:            strings.TrimSpace(string(url)),
+		body:           body,
+		headers:        headers,
+	}, nil
+}
+
+func (s *Server) sendCallback(route conf.RouteConfig, cb pendingCallback) {
+	if err := sleepContext(s.ctx, cb.Delay); err != nil {
+		return
+	}
+
+	interval := cb.RetryInterval
+	for attempt := 0; ; attempt++ {
+		status, err := s.deliverCallback(cb)
+		if err == nil {
+			s.metrics.callbacks.WithLabelValues(route.Path, "delivered").Inc()
+			s.logger.Info("Callback delivered", "url", cb.url, "code", status, "attempt", attempt+1)
+			return
+		}
+
+		if attempt >= cb.Retries {
+			s.metrics.callbacks.WithLabelValues(route.Path, "failed").Inc()
+			s.logger.Error("Callback failed", "url", cb.url, "attempts", attempt+1, "err", err)
+			return
+		}
+
+		s.logger.Warn("Callback attempt failed, retrying", "url", cb.url, "attempt", attempt+1, "in", interval, "err", err)
+		if err := sleepContext(s.ctx, interval); err != nil {
+			return
+		}
+		interval *= 2
+	}
+}
+
+// deliverCallback sends the callback once. Server errors count as failed
+// deliveries, client errors do not, since retrying will not fix them.
+func (s *Server) deliverCallback(cb pendingCallback) (int, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, cb.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, cb.Method, cb.url, bytes.NewReader(cb.body))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+
+	if len(cb.body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range cb.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
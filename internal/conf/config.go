@@ -2,50 +2,418 @@ package conf
 
 import (
 	"fmt"
+	"mime"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/kaato137/quickrest/internal/pkg/fixture"
+	"github.com/kaato137/quickrest/internal/pkg/marshal"
+	"github.com/kaato137/quickrest/internal/pkg/pathpattern"
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	RouteTypeHTTP      = "http"
+	RouteTypeSSE       = "sse"
+	RouteTypeWebSocket = "websocket"
+	RouteTypeGraphQL   = "graphql"
+)
+
+const (
+	LogFormatText   = "text"
+	LogFormatJSON   = "json"
+	LogFormatLogfmt = "logfmt"
+
+	AccessLogCombined = "combined"
+)
+
+const (
+	AuthTypeNone   = "none"
+	AuthTypeBasic  = "basic"
+	AuthTypeAPIKey = "api_key"
+	AuthTypeBearer = "bearer"
+)
+
+const (
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+	EncodingDeflate  = "deflate"
+	EncodingBrotli   = "br"
+)
+
+const (
+	PaginateStyleOffset = "offset"
+	PaginateStylePage   = "page"
+	PaginateStyleCursor = "cursor"
+)
+
 const (
 	defaultContentType    = "application/json"
+	defaultSSEContentType = "text/event-stream"
 	defaultStatusCode     = http.StatusOK
 	defaultReloadInterval = 2 * time.Second
 	defaultRecordDir      = "records"
+	defaultGraphQLPath    = "/graphql"
+	defaultGRPCAddress    = "localhost:50051"
+	defaultAPIKeyHeader   = "X-API-Key"
+	defaultAuthRealm      = "quickrest"
+	defaultOIDCPath       = "/oidc"
+	defaultOIDCTokenTTL   = time.Hour
+	defaultOIDCUser       = "user"
+
+	defaultScenarioState = "started"
+
+	defaultPageLimit    = 10
+	defaultMaxPageLimit = 100
+
+	defaultCallbackMethod        = http.MethodPost
+	defaultCallbackRetryInterval = time.Second
+	defaultCallbackTimeout       = 10 * time.Second
 )
 
+var defaultCORSMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+var defaultEncodings = []string{EncodingBrotli, EncodingGzip, EncodingDeflate}
+
+var paginateParams = []string{"offset", "limit", "page", "size", "cursor"}
+
+var paginateEnvelopeFields = []string{"items", "total", "offset", "limit", "page", "size", "next_cursor", "prev_cursor"}
+
 var defaultPaths = [...]string{
 	"quickrest.yml",
 	"quickrest.yaml",
 }
 
-var wildcardRegexp = regexp.MustCompile(`\{([a-zA-Z][a-zA-Z0-9_]*)\}`)
-
 type Config struct {
 	Address        string        `yaml:"addr"`
 	ReloadInterval time.Duration `yaml:"reload_interval"`
 	RecordDir      string        `yaml:"record_dir"`
+	UploadDir      string        `yaml:"upload_dir"`
+	AdminAddress   string        `yaml:"admin_addr"`
+	Seed           *int64        `yaml:"seed"`
+	Clock          *ClockConfig  `yaml:"clock"`
+	Log            LogConfig     `yaml:"log"`
+	CORS           *CORSConfig   `yaml:"cors"`
+	Auth           *AuthConfig   `yaml:"auth"`
+
+	// RecordCredentials keeps credential headers such as Authorization
+	// and Cookie in recordings, which are redacted otherwise.
+	RecordCredentials bool `yaml:"record_credentials"`
+
+	Compression *CompressionConfig `yaml:"compression"`
+	Conditional bool               `yaml:"conditional"`
+
+	Scenarios map[string]ScenarioConfig `yaml:"scenarios"`
+
+	// Fixtures are data files, by name, that scripts and templates can
+	// query. Paths are relative to the config file.
+	Fixtures map[string]string `yaml:"fixtures"`
 
-	Routes []RouteConfig `yaml:"routes"`
+	// NotFound and MethodNotAllowed answer requests no route matches,
+	// instead of the plain text errors of ServeMux.
+	NotFound         *RouteConfig `yaml:"not_found"`
+	MethodNotAllowed *RouteConfig `yaml:"method_not_allowed"`
+
+	Routes  []RouteConfig  `yaml:"routes"`
+	GraphQL *GraphQLConfig `yaml:"graphql"`
+	GRPC    *GRPCConfig    `yaml:"grpc"`
+	OIDC    *OIDCConfig    `yaml:"oidc"`
 
 	Path string
 }
 
+// ClockConfig sets the time told by now() helpers. A frozen clock only
+// moves when advanced through the admin API.
+type ClockConfig struct {
+	Time   time.Time `yaml:"time"`
+	Frozen bool      `yaml:"frozen"`
+}
+
+// LogConfig controls the server logs. Access selects how requests are
+// logged: by default a pair of structured lines per request, or a single
+// Apache style line with "combined".
+type LogConfig struct {
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+	File   string `yaml:"file"`
+	Access string `yaml:"access"`
+}
+
+// CORSConfig answers cross-origin requests. Routes without their own block
+// use the top-level one. Origins may contain "*" wildcards, and without
+// allowed headers the headers asked for in a preflight are allowed.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// CompressionConfig compresses bodies the client accepts compressed.
+// Like CORS, routes without their own block use the top-level one. Force
+// picks an encoding whatever the client accepts ("identity" turns
+// compression off), and ContentEncoding replaces the header sent, to
+// test clients against misbehaving servers.
+type CompressionConfig struct {
+	Encodings       []string `yaml:"encodings"`
+	Force           string   `yaml:"force"`
+	ContentEncoding string   `yaml:"content_encoding"`
+	MinSize         int      `yaml:"min_size"`
+}
+
+// PaginateConfig slices the JSON array a route responds with. Params
+// renames the query parameters (offset, limit, page, size, cursor), and
+// Envelope wraps the page in an object, naming the fields for the items,
+// total, offset, limit, page, size, next_cursor and prev_cursor. Without
+// an envelope the bare array is sent.
+type PaginateConfig struct {
+	Style        string            `yaml:"style"`
+	DefaultLimit int               `yaml:"default_limit"`
+	MaxLimit     int               `yaml:"max_limit"`
+	Params       map[string]string `yaml:"params"`
+	Envelope     map[string]string `yaml:"envelope"`
+}
+
+// AuthConfig protects routes. Like CORS, a top-level block applies to
+// every route without its own, and "none" opts a route out.
+type AuthConfig struct {
+	Type  string `yaml:"type"`
+	Realm string `yaml:"realm"`
+
+	// Users maps user names to passwords for basic auth.
+	Users map[string]string `yaml:"users"`
+
+	// Header or Query carries one of Keys for API key auth.
+	Header string   `yaml:"header"`
+	Query  string   `yaml:"query"`
+	Keys   []string `yaml:"keys"`
+
+	// Bearer auth accepts the opaque Tokens as they are, and JWTs
+	// verified with JWT.
+	Tokens []string   `yaml:"tokens"`
+	JWT    *JWTConfig `yaml:"jwt"`
+}
+
+// JWTConfig verifies bearer JWTs with a shared secret (HMAC) or the keys
+// of a JWKS, given as a URL or a file.
+type JWTConfig struct {
+	Secret   string        `yaml:"secret"`
+	JWKS     string        `yaml:"jwks"`
+	Issuer   string        `yaml:"issuer"`
+	Audience string        `yaml:"audience"`
+	Scopes   []string      `yaml:"scopes"`
+	Leeway   time.Duration `yaml:"leeway"`
+}
+
 type RouteConfig struct {
-	Path        string            `yaml:"path"`
-	Body        string            `yaml:"body"`
-	BodyJS      string            `yaml:"body_js"`
-	ContentType string            `yaml:"content_type"`
-	Headers     map[string]string `yaml:"headers"`
-	StatusCode  int               `yaml:"status"`
-	Record      bool              `yaml:"record"`
-	Latency     time.Duration     `yaml:"latency"`
-	Jitter      time.Duration     `yaml:"jitter"`
+	Type         string            `yaml:"type"`
+	Path         string            `yaml:"path"`
+	Priority     int               `yaml:"priority"`
+	PathRegex    string            `yaml:"path_regex"`
+	Method       string            `yaml:"method"`
+	Body         string            `yaml:"body"`
+	BodyJS       string            `yaml:"body_js"`
+	BodyTemplate string            `yaml:"body_template"`
+	ContentType  string            `yaml:"content_type"`
+	Bodies       map[string]string `yaml:"bodies"`
+	Headers      map[string]string `yaml:"headers"`
+	StatusCode   int               `yaml:"status"`
+	Record       bool              `yaml:"record"`
+	Latency      time.Duration     `yaml:"latency"`
+	Jitter       time.Duration     `yaml:"jitter"`
+	FakerSeed    string            `yaml:"faker_seed"`
+	CORS         *CORSConfig       `yaml:"cors"`
+	Auth         *AuthConfig       `yaml:"auth"`
+
+	Compression  *CompressionConfig `yaml:"compression"`
+	Conditional  *bool              `yaml:"conditional"`
+	LastModified time.Time          `yaml:"last_modified"`
+	Paginate     *PaginateConfig    `yaml:"paginate"`
+
+	// Structured bodies are written as YAML and encoded when served.
+	JSON    any `yaml:"json"`
+	YAML    any `yaml:"yaml"`
+	XML     any `yaml:"xml"`
+	MsgPack any `yaml:"msgpack"`
+
+	Callbacks []CallbackConfig `yaml:"callbacks"`
+
+	Scenario      string `yaml:"scenario"`
+	RequiredState string `yaml:"required_state"`
+	NewState      string `yaml:"new_state"`
+
+	Events   []EventConfig `yaml:"events"`
+	EventsJS string        `yaml:"events_js"`
+	Loop     bool          `yaml:"loop"`
+
+	WebSocket WebSocketConfig `yaml:"websocket"`
+	GraphQL   *GraphQLConfig  `yaml:"graphql"`
+
+	// wildcards and pathMatcher are derived from the path by Prepare.
+	wildcards   []string
+	pathMatcher *regexp.Regexp
+}
+
+// Wildcards are the names of the path wildcards, or of the named groups of
+// path_regex.
+func (r *RouteConfig) Wildcards() []string {
+	return r.wildcards
+}
+
+// PathMatcher is set for routes ServeMux cannot serve: path_regex routes
+// and paths with typed wildcards.
+func (r *RouteConfig) PathMatcher() *regexp.Regexp {
+	return r.pathMatcher
+}
+
+// ScenarioConfig is a state machine shared by routes. States, when given,
+// lists every valid state so that typos are caught on load.
+type ScenarioConfig struct {
+	Initial string   `yaml:"initial"`
+	States  []string `yaml:"states"`
+}
+
+// CallbackConfig sends a request after the route responded. URL, Body and
+// header values are Go templates over the incoming request. Failed
+// attempts (network errors and 5xx) are retried with a doubling interval.
+type CallbackConfig struct {
+	URL           string            `yaml:"url"`
+	Method        string            `yaml:"method"`
+	Headers       map[string]string `yaml:"headers"`
+	Body          string            `yaml:"body"`
+	Delay         time.Duration     `yaml:"delay"`
+	Retries       int               `yaml:"retries"`
+	RetryInterval time.Duration     `yaml:"retry_interval"`
+	Timeout       time.Duration     `yaml:"timeout"`
+}
+
+type EventConfig struct {
+	ID    string        `yaml:"id"`
+	Event string        `yaml:"event"`
+	Data  string        `yaml:"data"`
+	Retry time.Duration `yaml:"retry"`
+	Delay time.Duration `yaml:"delay"`
+}
+
+type WebSocketConfig struct {
+	OnConnect []WSMessageConfig  `yaml:"on_connect"`
+	Replies   []WSReplyConfig    `yaml:"replies"`
+	Periodic  []WSPeriodicConfig `yaml:"periodic"`
+	Close     *WSCloseConfig     `yaml:"close"`
+}
+
+type WSMessageConfig struct {
+	Send   string        `yaml:"send"`
+	SendJS string        `yaml:"send_js"`
+	Delay  time.Duration `yaml:"delay"`
+}
+
+type WSReplyConfig struct {
+	WSMessageConfig `yaml:",inline"`
+
+	Match     string         `yaml:"match"`
+	MatchJSON map[string]any `yaml:"match_json"`
+	Close     *WSCloseConfig `yaml:"close"`
+
+	matchRegexp *regexp.Regexp
+}
+
+// MatchRegexp is the compiled Match, or nil without one.
+func (r *WSReplyConfig) MatchRegexp() *regexp.Regexp {
+	return r.matchRegexp
+}
+
+type WSPeriodicConfig struct {
+	WSMessageConfig `yaml:",inline"`
+
+	Interval time.Duration `yaml:"interval"`
+}
+
+type WSCloseConfig struct {
+	Code   int           `yaml:"code"`
+	Reason string        `yaml:"reason"`
+	After  time.Duration `yaml:"after"`
+}
+
+type GraphQLConfig struct {
+	Schema     string                    `yaml:"schema"`
+	Path       string                    `yaml:"path"`
+	ListLength int                       `yaml:"list_length"`
+	Resolvers  map[string]ResolverConfig `yaml:"resolvers"`
+}
+
+// ResolverConfig overrides a field of a GraphQL type. Resolvers are keyed
+// by "Type.field", e.g. "Query.user".
+type ResolverConfig struct {
+	Value any    `yaml:"value"`
+	JS    string `yaml:"js"`
+}
+
+// OIDCConfig runs a mock OpenID Connect provider under Path. Without
+// clients any client is accepted, and without an issuer it is derived
+// from the request.
+type OIDCConfig struct {
+	Issuer   string             `yaml:"issuer"`
+	Path     string             `yaml:"path"`
+	Login    bool               `yaml:"login"`
+	TokenTTL time.Duration      `yaml:"token_ttl"`
+	Clients  []OIDCClientConfig `yaml:"clients"`
+	Users    []OIDCUserConfig   `yaml:"users"`
+}
+
+type OIDCClientConfig struct {
+	ID           string   `yaml:"client_id"`
+	Secret       string   `yaml:"client_secret"`
+	RedirectURIs []string `yaml:"redirect_uris"`
+}
+
+// OIDCUserConfig is a user who can log in. Claims end up in ID tokens,
+// access tokens and userinfo.
+type OIDCUserConfig struct {
+	Username string         `yaml:"username"`
+	Password string         `yaml:"password"`
+	Claims   map[string]any `yaml:"claims"`
+}
 
-	Wildcards []string
+type GRPCConfig struct {
+	Address       string             `yaml:"addr"`
+	Protos        []string           `yaml:"protos"`
+	ImportPaths   []string           `yaml:"import_paths"`
+	DescriptorSet string             `yaml:"descriptor_set"`
+	Reflection    bool               `yaml:"reflection"`
+	Methods       []GRPCMethodConfig `yaml:"methods"`
+}
+
+// GRPCMethodConfig describes the answer of a gRPC method. Messages are
+// written as JSON (or native YAML) and converted to protobuf. Method names
+// look like "/package.Service/Method".
+type GRPCMethodConfig struct {
+	Method         string            `yaml:"method"`
+	Response       any               `yaml:"response"`
+	ResponseJS     string            `yaml:"response_js"`
+	Stream         []any             `yaml:"stream"`
+	StreamInterval time.Duration     `yaml:"stream_interval"`
+	Code           string            `yaml:"code"`
+	Message        string            `yaml:"message"`
+	Headers        map[string]string `yaml:"headers"`
+	Trailers       map[string]string `yaml:"trailers"`
+	Record         bool              `yaml:"record"`
+	Latency        time.Duration     `yaml:"latency"`
+	Jitter         time.Duration     `yaml:"jitter"`
 }
 
 func LoadConfigFromFile(path string) (*Config, error) {
@@ -70,22 +438,152 @@ func LoadConfigFromFile(path string) (*Config, error) {
 		return nil, fmt.Errorf("decode config: %w", err)
 	}
 
-	enrichConfig(&cfg, path)
+	cfg.Path = path
+	if err := Prepare(&cfg); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
 
-func enrichConfig(cfg *Config, path string) {
-	cfg.Path = path
+// Prepare fills in defaults and validates a configuration that was built
+// in code rather than loaded from a file. Preparing a configuration twice
+// is harmless.
+func Prepare(cfg *Config) error {
+	enrichConfig(cfg)
+
+	if err := validateConfig(cfg); err != nil {
+		return fmt.Errorf("validate config: %w", err)
+	}
+
+	return nil
+}
 
+// ResolvePath makes p relative to the directory of the configuration file
+// unless it is already absolute.
+func (c *Config) ResolvePath(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+
+	return filepath.Join(filepath.Dir(c.Path), p)
+}
+
+func enrichConfig(cfg *Config) {
 	setDefaults(cfg)
 
+	if cfg.GraphQL != nil && !hasGraphQLRoute(cfg) {
+		cfg.Routes = append(cfg.Routes, RouteConfig{
+			Type:    RouteTypeGraphQL,
+			Path:    cfg.GraphQL.Path,
+			GraphQL: cfg.GraphQL,
+		})
+	}
+
+	if cfg.CORS != nil {
+		setCORSDefaults(cfg.CORS)
+	}
+
+	if cfg.Compression != nil {
+		setCompressionDefaults(cfg.Compression)
+	}
+
 	for i := range cfg.Routes {
 		resolvePlaceholders(&cfg.Routes[i])
 		setRouteDefaults(&cfg.Routes[i])
+
+		if cfg.Routes[i].CORS == nil {
+			cfg.Routes[i].CORS = cfg.CORS
+		} else {
+			setCORSDefaults(cfg.Routes[i].CORS)
+		}
+
+		if cfg.Routes[i].Auth == nil {
+			cfg.Routes[i].Auth = cfg.Auth
+		}
+
+		if cfg.Routes[i].Compression == nil {
+			cfg.Routes[i].Compression = cfg.Compression
+		} else {
+			setCompressionDefaults(cfg.Routes[i].Compression)
+		}
+
+		if cfg.Routes[i].Conditional == nil {
+			cfg.Routes[i].Conditional = &cfg.Conditional
+		}
+
+		if cfg.Routes[i].Auth != nil {
+			setAuthDefaults(cfg.Routes[i].Auth)
+		}
+	}
+
+	setFallbackDefaults(cfg, cfg.NotFound, http.StatusNotFound)
+	setFallbackDefaults(cfg, cfg.MethodNotAllowed, http.StatusMethodNotAllowed)
+
+	if cfg.GRPC != nil {
+		setGRPCDefaults(cfg.GRPC)
+	}
+
+	if cfg.OIDC != nil {
+		setOIDCDefaults(cfg.OIDC)
+	}
+
+	setScenarioDefaults(cfg)
+}
+
+// setScenarioDefaults defines the scenarios that routes refer to without
+// declaring them, and sets the initial states.
+func setScenarioDefaults(cfg *Config) {
+	for _, r := range cfg.Routes {
+		if r.Scenario == "" {
+			continue
+		}
+
+		if cfg.Scenarios == nil {
+			cfg.Scenarios = make(map[string]ScenarioConfig)
+		}
+
+		if _, ok := cfg.Scenarios[r.Scenario]; !ok {
+			cfg.Scenarios[r.Scenario] = ScenarioConfig{}
+		}
+	}
+
+	for name, sc := range cfg.Scenarios {
+		if sc.Initial == "" {
+			sc.Initial = defaultScenarioState
+			if len(sc.States) > 0 {
+				sc.Initial = sc.States[0]
+			}
+		}
+		cfg.Scenarios[name] = sc
+	}
+}
+
+func setOIDCDefaults(o *OIDCConfig) {
+	if o.Path == "" {
+		o.Path = defaultOIDCPath
+	}
+	o.Path = "/" + strings.Trim(o.Path, "/")
+
+	if o.TokenTTL == 0 {
+		o.TokenTTL = defaultOIDCTokenTTL
+	}
+
+	if len(o.Users) == 0 {
+		o.Users = []OIDCUserConfig{{Username: defaultOIDCUser}}
 	}
 }
 
+func hasGraphQLRoute(cfg *Config) bool {
+	for _, r := range cfg.Routes {
+		if r.GraphQL == cfg.GraphQL {
+			return true
+		}
+	}
+
+	return false
+}
+
 func setDefaults(cfg *Config) {
 	if cfg.ReloadInterval == 0 {
 		cfg.ReloadInterval = defaultReloadInterval
@@ -97,26 +595,497 @@ func setDefaults(cfg *Config) {
 }
 
 func setRouteDefaults(r *RouteConfig) {
+	if r.Type == "" {
+		r.Type = RouteTypeHTTP
+	}
+
 	if r.ContentType == "" {
 		r.ContentType = defaultContentType
+		if format, _ := r.StructuredBody(); format != "" {
+			r.ContentType = marshal.ContentTypes[format]
+		} else if r.Type == RouteTypeSSE {
+			r.ContentType = defaultSSEContentType
+		}
 	}
 
 	if r.StatusCode == 0 {
 		r.StatusCode = defaultStatusCode
 	}
+
+	if r.GraphQL != nil && r.Path == "" {
+		r.Path = defaultGraphQLPath
+	}
+
+	// The path of a regex route only names it in logs and metrics.
+	if r.PathRegex != "" && r.Path == "" {
+		r.Path = strings.TrimSpace(r.Method + " " + r.PathRegex)
+	} else if r.PathRegex == "" && r.Method != "" && !strings.Contains(r.Path, " ") {
+		r.Path = r.Method + " " + r.Path
+	}
+
+	for i := range r.Callbacks {
+		setCallbackDefaults(&r.Callbacks[i])
+	}
+
+	if r.Paginate != nil {
+		setPaginateDefaults(r.Paginate)
+	}
+}
+
+// setFallbackDefaults prepares a not_found or method_not_allowed response.
+// It shares the top-level CORS, so that browsers can read the error.
+func setFallbackDefaults(cfg *Config, r *RouteConfig, status int) {
+	if r == nil {
+		return
+	}
+
+	if r.StatusCode == 0 {
+		r.StatusCode = status
+	}
+
+	resolvePlaceholders(r)
+	setRouteDefaults(r)
+
+	if r.CORS == nil {
+		r.CORS = cfg.CORS
+	} else {
+		setCORSDefaults(r.CORS)
+	}
+}
+
+func setPaginateDefaults(p *PaginateConfig) {
+	if p.Style == "" {
+		p.Style = PaginateStyleOffset
+	}
+
+	if p.DefaultLimit == 0 {
+		p.DefaultLimit = defaultPageLimit
+	}
+
+	if p.MaxLimit == 0 {
+		p.MaxLimit = defaultMaxPageLimit
+	}
+}
+
+func setCallbackDefaults(c *CallbackConfig) {
+	if c.Method == "" {
+		c.Method = defaultCallbackMethod
+	}
+
+	if c.RetryInterval == 0 {
+		c.RetryInterval = defaultCallbackRetryInterval
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = defaultCallbackTimeout
+	}
+}
+
+func setCORSDefaults(c *CORSConfig) {
+	if len(c.AllowedOrigins) == 0 {
+		c.AllowedOrigins = []string{"*"}
+	}
+
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = defaultCORSMethods
+	}
+}
+
+func setCompressionDefaults(c *CompressionConfig) {
+	if len(c.Encodings) == 0 {
+		c.Encodings = defaultEncodings
+	}
+}
+
+func setAuthDefaults(a *AuthConfig) {
+	if a.Realm == "" {
+		a.Realm = defaultAuthRealm
+	}
+
+	if a.Type == AuthTypeAPIKey && a.Header == "" && a.Query == "" {
+		a.Header = defaultAPIKeyHeader
+	}
+}
+
+func setGRPCDefaults(g *GRPCConfig) {
+	if g.Address == "" {
+		g.Address = defaultGRPCAddress
+	}
+
+	for i := range g.Methods {
+		if !strings.HasPrefix(g.Methods[i].Method, "/") {
+			g.Methods[i].Method = "/" + g.Methods[i].Method
+		}
+	}
 }
 
 func resolvePlaceholders(r *RouteConfig) {
-	results := wildcardRegexp.FindAllStringSubmatch(r.Path, -1)
+	if r.PathRegex != "" {
+		return
+	}
 
-	if len(results) == 0 {
+	// A malformed path is reported by validateRoute.
+	pattern, err := pathpattern.Parse(r.Path)
+	if err != nil {
 		return
 	}
 
-	submatches := make([]string, 0, len(results))
-	for i := range results {
-		submatches = append(submatches, results[i][1])
+	var wildcards []string
+	for _, w := range pattern.Wildcards() {
+		wildcards = append(wildcards, w.Name)
+	}
+
+	r.wildcards = wildcards
+}
+
+// compilePath sets the path matcher of regex routes and of paths with typed
+// wildcards. The capture groups of path_regex become the wildcards.
+func compilePath(r *RouteConfig) error {
+	if r.PathRegex != "" {
+		re, err := regexp.Compile(`^(?:` + r.PathRegex + `)$`)
+		if err != nil {
+			return fmt.Errorf("path_regex: %w", err)
+		}
+
+		var wildcards []string
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				wildcards = append(wildcards, name)
+			}
+		}
+
+		r.pathMatcher = re
+		r.wildcards = wildcards
+
+		return nil
+	}
+
+	pattern, err := pathpattern.Parse(r.Path)
+	if err != nil {
+		return err
+	}
+
+	if !pattern.Typed() {
+		return nil
+	}
+
+	re, err := pattern.Regexp()
+	if err != nil {
+		return fmt.Errorf("typed wildcard: %w", err)
+	}
+
+	r.pathMatcher = re
+	if r.Method == "" {
+		r.Method = pattern.Method
+	}
+
+	return nil
+}
+
+func validateConfig(cfg *Config) error {
+	for i := range cfg.Routes {
+		if err := validateRoute(&cfg.Routes[i]); err != nil {
+			return fmt.Errorf("route %q: %w", cfg.Routes[i].Path, err)
+		}
+
+		if err := validateRouteScenario(cfg, &cfg.Routes[i]); err != nil {
+			return fmt.Errorf("route %q: %w", cfg.Routes[i].Path, err)
+		}
+	}
+
+	if cfg.NotFound != nil {
+		if err := validateFallback(cfg.NotFound); err != nil {
+			return fmt.Errorf("not_found: %w", err)
+		}
+	}
+
+	if cfg.MethodNotAllowed != nil {
+		if err := validateFallback(cfg.MethodNotAllowed); err != nil {
+			return fmt.Errorf("method_not_allowed: %w", err)
+		}
+	}
+
+	for name, path := range cfg.Fixtures {
+		if !fixture.Supported(path) {
+			return fmt.Errorf("fixture %q: %w: %q", name, ErrUnknownFixtureFormat, path)
+		}
+	}
+
+	for name, sc := range cfg.Scenarios {
+		if len(sc.States) > 0 && !slices.Contains(sc.States, sc.Initial) {
+			return fmt.Errorf("scenario %q: %w: %q", name, ErrUnknownState, sc.Initial)
+		}
+	}
+
+	if cfg.GRPC != nil {
+		if err := validateGRPC(cfg.GRPC); err != nil {
+			return fmt.Errorf("grpc: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validateGRPC(g *GRPCConfig) error {
+	if len(g.Protos) == 0 && g.DescriptorSet == "" {
+		return ErrNoProtos
+	}
+
+	for i := range g.Methods {
+		if g.Methods[i].Method == "/" {
+			return fmt.Errorf("method %d: %w", i, ErrNoMethodName)
+		}
+	}
+
+	return nil
+}
+
+func validateRouteScenario(cfg *Config, r *RouteConfig) error {
+	if r.Scenario == "" {
+		if r.RequiredState != "" || r.NewState != "" {
+			return ErrNoScenario
+		}
+		return nil
+	}
+
+	states := cfg.Scenarios[r.Scenario].States
+	if len(states) == 0 {
+		return nil
+	}
+
+	for _, state := range []string{r.RequiredState, r.NewState} {
+		if state != "" && !slices.Contains(states, state) {
+			return fmt.Errorf("scenario %q: %w: %q", r.Scenario, ErrUnknownState, state)
+		}
+	}
+
+	return nil
+}
+
+func validatePaginate(p *PaginateConfig) error {
+	switch p.Style {
+	case PaginateStyleOffset, PaginateStylePage, PaginateStyleCursor:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownPaginateStyle, p.Style)
+	}
+
+	if p.DefaultLimit < 1 || p.MaxLimit < 1 {
+		return ErrInvalidPageLimit
+	}
+
+	for role := range p.Params {
+		if !slices.Contains(paginateParams, role) {
+			return fmt.Errorf("params: %w: %q", ErrUnknownPaginateField, role)
+		}
+	}
+
+	for role := range p.Envelope {
+		if !slices.Contains(paginateEnvelopeFields, role) {
+			return fmt.Errorf("envelope: %w: %q", ErrUnknownPaginateField, role)
+		}
+	}
+
+	return nil
+}
+
+func validateCompression(c *CompressionConfig) error {
+	for _, encoding := range append([]string{c.Force}, c.Encodings...) {
+		switch encoding {
+		case "", EncodingIdentity, EncodingGzip, EncodingDeflate, EncodingBrotli:
+		default:
+			return fmt.Errorf("%w: %q", ErrUnknownEncoding, encoding)
+		}
+	}
+
+	return nil
+}
+
+func validateAuth(a *AuthConfig) error {
+	switch a.Type {
+	case AuthTypeNone:
+	case AuthTypeBasic:
+		if len(a.Users) == 0 {
+			return ErrNoCredentials
+		}
+	case AuthTypeAPIKey:
+		if len(a.Keys) == 0 {
+			return ErrNoCredentials
+		}
+	case AuthTypeBearer:
+		if len(a.Tokens) == 0 && a.JWT == nil {
+			return ErrNoCredentials
+		}
+		if a.JWT != nil && a.JWT.Secret == "" && a.JWT.JWKS == "" {
+			return ErrNoJWTKey
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownAuthType, a.Type)
+	}
+
+	return nil
+}
+
+// StructuredBody returns the format and value of a json, yaml, xml or
+// msgpack body. The format is empty when the route has none.
+func (r *RouteConfig) StructuredBody() (string, any) {
+	for _, body := range r.structuredBodies() {
+		if body.value != nil {
+			return body.format, body.value
+		}
+	}
+
+	return "", nil
+}
+
+type structuredBody struct {
+	format string
+	value  any
+}
+
+func (r *RouteConfig) structuredBodies() []structuredBody {
+	return []structuredBody{
+		{marshal.FormatJSON, r.JSON},
+		{marshal.FormatYAML, r.YAML},
+		{marshal.FormatXML, r.XML},
+		{marshal.FormatMsgPack, r.MsgPack},
+	}
+}
+
+// validateStructuredBody encodes the body once, so that mistakes show up
+// when the config is loaded rather than on the first request.
+func validateStructuredBody(r *RouteConfig) error {
+	count := 0
+	for _, body := range r.structuredBodies() {
+		if body.value != nil {
+			count++
+		}
+	}
+	if count > 1 {
+		return ErrMultipleBodies
+	}
+
+	format, value := r.StructuredBody()
+	if format == "" {
+		return nil
+	}
+
+	if _, err := marshal.Marshal(format, value); err != nil {
+		return fmt.Errorf("%s: %w", format, err)
+	}
+
+	return nil
+}
+
+func validateMediaType(mediaType string) error {
+	parsed, _, err := mime.ParseMediaType(mediaType)
+	if err != nil || strings.Contains(parsed, "*") {
+		return fmt.Errorf("%w: %q", ErrInvalidMediaType, mediaType)
+	}
+
+	return nil
+}
+
+func validateFallback(r *RouteConfig) error {
+	if r.Type != RouteTypeHTTP {
+		return fmt.Errorf("%w: %q", ErrFallbackType, r.Type)
+	}
+
+	return validateRoute(r)
+}
+
+// scriptGlobals are the names scripts get from QuickREST and JavaScript.
+// Path wildcards become script globals as well, so they may not hide them.
+var scriptGlobals = []string{
+	"int", "uuid", "now", "faker", "claims", "fixtures", "form", "files",
+	"emit", "sleep", "message", "args", "parent", "request", "requests", "item",
+	"Math", "JSON", "Object", "Array", "String", "Number", "Date",
+}
+
+func validateRoute(r *RouteConfig) error {
+	if err := compilePath(r); err != nil {
+		return err
+	}
+
+	for _, wc := range r.wildcards {
+		if slices.Contains(scriptGlobals, wc) {
+			return fmt.Errorf("%w: %q", ErrReservedWildcard, wc)
+		}
+	}
+
+	if r.Auth != nil {
+		if err := validateAuth(r.Auth); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := validateStructuredBody(r); err != nil {
+		return err
+	}
+
+	if r.Paginate != nil {
+		if err := validatePaginate(r.Paginate); err != nil {
+			return fmt.Errorf("paginate: %w", err)
+		}
+	}
+
+	if r.Compression != nil {
+		if err := validateCompression(r.Compression); err != nil {
+			return fmt.Errorf("compression: %w", err)
+		}
+	}
+
+	for mediaType := range r.Bodies {
+		if err := validateMediaType(mediaType); err != nil {
+			return fmt.Errorf("bodies: %w", err)
+		}
+	}
+
+	for i := range r.Callbacks {
+		if r.Callbacks[i].URL == "" {
+			return fmt.Errorf("callback %d: %w", i, ErrNoCallbackURL)
+		}
+	}
+
+	switch r.Type {
+	case RouteTypeHTTP:
+		return nil
+	case RouteTypeSSE:
+		if len(r.Events) == 0 && r.EventsJS == "" {
+			return ErrNoEvents
+		}
+		return nil
+	case RouteTypeWebSocket:
+		return validateWebSocket(&r.WebSocket)
+	case RouteTypeGraphQL:
+		if r.GraphQL == nil || r.GraphQL.Schema == "" {
+			return ErrNoGraphQLSchema
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownRouteType, r.Type)
+	}
+}
+
+func validateWebSocket(ws *WebSocketConfig) error {
+	for i := range ws.Replies {
+		reply := &ws.Replies[i]
+
+		if reply.Match == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(reply.Match)
+		if err != nil {
+			return fmt.Errorf("reply %d: %w", i, err)
+		}
+		reply.matchRegexp = re
+	}
+
+	for i := range ws.Periodic {
+		if ws.Periodic[i].Interval <= 0 {
+			return fmt.Errorf("periodic %d: %w", i, ErrInvalidInterval)
+		}
 	}
 
-	r.Wildcards = submatches
+	return nil
 }
@@ -6,4 +6,25 @@ var (
 	ErrConfigFileNotExist         = errors.New("file not exist")
 	ErrDefaultPathNotFound        = errors.New("defaut path not found")
 	ErrDefaultConfigAlreadyExists = errors.New("default config already exists")
+	ErrUnknownRouteType           = errors.New("unknown route type")
+	ErrNoEvents                   = errors.New("sse route must define events or events_js")
+	ErrInvalidInterval            = errors.New("interval must be positive")
+	ErrNoGraphQLSchema            = errors.New("graphql route must define a schema")
+	ErrNoProtos                   = errors.New("protos or descriptor_set must be defined")
+	ErrNoMethodName               = errors.New("method name must be defined")
+	ErrUnknownAuthType            = errors.New("unknown auth type")
+	ErrNoCredentials              = errors.New("auth must define users, keys, tokens or jwt")
+	ErrNoJWTKey                   = errors.New("jwt must define a secret or jwks")
+	ErrNoCallbackURL              = errors.New("callback must define a url")
+	ErrNoScenario                 = errors.New("required_state and new_state need a scenario")
+	ErrFallbackType               = errors.New("fallback responses must be http routes")
+	ErrInvalidMediaType           = errors.New("invalid media type")
+	ErrMultipleBodies             = errors.New("only one of json, yaml, xml and msgpack may be set")
+	ErrUnknownEncoding            = errors.New("unknown encoding")
+	ErrUnknownPaginateStyle       = errors.New("unknown paginate style")
+	ErrUnknownPaginateField       = errors.New("unknown paginate field")
+	ErrInvalidPageLimit           = errors.New("page limits must be positive")
+	ErrUnknownFixtureFormat       = errors.New("fixtures must be json, yaml or csv files")
+	ErrUnknownState               = errors.New("unknown scenario state")
+	ErrReservedWildcard           = errors.New("wildcard name is taken by a script global")
 )
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/kaato137/quickrest/internal/conf"
+)
+
+// corsRouter answers preflight requests for every route with CORS enabled,
+// so that no OPTIONS routes have to be written by hand. A configured
+// OPTIONS route still takes precedence.
+type corsRouter struct {
+	router *router
+	logger Logger
+}
+
+func (c *corsRouter) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if isPreflight(r) {
+		if route, ok := c.preflightRoute(r); ok {
+			c.logger.Debug("Preflight answered", "url", r.URL.String(), "origin", r.Header.Get("Origin"))
+			writePreflight(rw, r, route.CORS)
+			return
+		}
+	}
+
+	c.router.ServeHTTP(rw, r)
+}
+
+// preflightRoute finds the route the browser is going to call.
+func (c *corsRouter) preflightRoute(r *http.Request) (conf.RouteConfig, bool) {
+	if route, ok := c.router.find(r); ok && isOptionsRoute(route) {
+		return conf.RouteConfig{}, false
+	}
+
+	probe := r.Clone(r.Context())
+	probe.Method = r.Header.Get("Access-Control-Request-Method")
+
+	route, ok := c.router.find(probe)
+
+	return route, ok && route.CORS != nil
+}
+
+func isOptionsRoute(route conf.RouteConfig) bool {
+	return route.Method == http.MethodOptions || strings.HasPrefix(route.Path, http.MethodOptions+" ")
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+func writePreflight(rw http.ResponseWriter, r *http.Request, cors *conf.CORSConfig) {
+	h := rw.Header()
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	if applyCORS(h, r, cors) {
+		h.Set("Access-Control-Allow-Methods", strings.Join(cors.AllowedMethods, ", "))
+
+		if len(cors.AllowedHeaders) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(cors.AllowedHeaders, ", "))
+		} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			h.Set("Access-Control-Allow-Headers", requested)
+		}
+
+		if cors.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+		}
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// writeCORS adds the CORS headers of an actual, non-preflight request.
+func writeCORS(rw http.ResponseWriter, r *http.Request, cors *conf.CORSConfig) {
+	h := rw.Header()
+	if applyCORS(h, r, cors) && len(cors.ExposedHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(cors.ExposedHeaders, ", "))
+	}
+}
+
+// applyCORS sets the headers shared by preflight and actual requests. It
+// reports whether the origin is allowed.
+func applyCORS(h http.Header, r *http.Request, cors *conf.CORSConfig) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	h.Add("Vary", "Origin")
+
+	if !originAllowed(cors.AllowedOrigins, origin) {
+		return false
+	}
+
+	// Browsers refuse "*" together with credentials.
+	if len(cors.AllowedOrigins) == 1 && cors.AllowedOrigins[0] == "*" && !cors.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if cors.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	return true
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if pattern == "*" || strings.EqualFold(pattern, origin) {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,355 @@
+package internal
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/recording"
+)
+
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+// dashboardBodyLimit caps the request and response bodies shown on the
+// dashboard, so that large payloads do not pile up in the browser.
+const dashboardBodyLimit = 64 << 10
+
+var (
+	errUnknownRoute    = errors.New("unknown route")
+	errUnknownResponse = errors.New("unknown response")
+)
+
+// activity fans requests and reloads out to the dashboards watching them.
+type activity struct {
+	mutex       sync.Mutex
+	subscribers map[chan activityEvent]struct{}
+	reload      *reloadStatus
+}
+
+type activityEvent struct {
+	Type    string           `json:"type"`
+	Request *requestActivity `json:"request,omitempty"`
+	Reload  *reloadStatus    `json:"reload,omitempty"`
+}
+
+type requestActivity struct {
+	ID              uint64      `json:"id"`
+	Time            time.Time   `json:"time"`
+	Route           string      `json:"route"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	Headers         http.Header `json:"headers"`
+	Body            string      `json:"body"`
+	Status          int         `json:"status"`
+	ResponseHeaders http.Header `json:"response_headers"`
+	ResponseBody    string      `json:"response_body"`
+	Took            float64     `json:"took_ms"`
+}
+
+type reloadStatus struct {
+	Time  time.Time `json:"time"`
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+}
+
+// routeState describes a route. Routes sharing a path are the responses of
+// the path, numbered in configuration order; Active marks the one pinned
+// from the dashboard.
+type routeState struct {
+	Path          string `json:"path"`
+	Type          string `json:"type"`
+	Status        int    `json:"status"`
+	ContentType   string `json:"content_type"`
+	Enabled       bool   `json:"enabled"`
+	Response      int    `json:"response"`
+	Responses     int    `json:"responses"`
+	RequiredState string `json:"required_state,omitempty"`
+	Active        bool   `json:"active"`
+}
+
+func newActivity() *activity {
+	return &activity{subscribers: make(map[chan activityEvent]struct{})}
+}
+
+// watched reports whether anybody listens, so that requests are only
+// captured while a dashboard is open.
+func (a *activity) watched() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return len(a.subscribers) > 0
+}
+
+func (a *activity) subscribe() chan activityEvent {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	ch := make(chan activityEvent, 64)
+	a.subscribers[ch] = struct{}{}
+
+	return ch
+}
+
+func (a *activity) unsubscribe(ch chan activityEvent) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	delete(a.subscribers, ch)
+}
+
+// publish never blocks. Slow dashboards miss events instead of slowing
+// the mock down.
+func (a *activity) publish(ev activityEvent) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for ch := range a.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (a *activity) reloaded(err error) {
+	status := &reloadStatus{Time: time.Now(), OK: err == nil}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	a.mutex.Lock()
+	a.reload = status
+	a.mutex.Unlock()
+
+	a.publish(activityEvent{Type: "reload", Reload: status})
+}
+
+func (a *activity) lastReload() *reloadStatus {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.reload
+}
+
+func (s *Server) routeEnabled(route conf.RouteConfig) bool {
+	_, disabled := s.disabledRoutes.Load(route.Path)
+	return !disabled
+}
+
+func (s *Server) pinnedResponse(path string) (int, bool) {
+	response, ok := s.pinnedResponses.Load(path)
+	if !ok {
+		return 0, false
+	}
+
+	return response.(int), true
+}
+
+// responseNumbers numbers every route among the routes sharing its path,
+// in configuration order.
+func responseNumbers(routes []conf.RouteConfig) []int {
+	numbers := make([]int, len(routes))
+	counts := make(map[string]int)
+	for i, route := range routes {
+		numbers[i] = counts[route.Path]
+		counts[route.Path]++
+	}
+
+	return numbers
+}
+
+func (s *Server) handleDashboard(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = rw.Write(dashboardPage)
+}
+
+func (s *Server) handleGetRoutes(rw http.ResponseWriter, r *http.Request) {
+	s.cfgMutex.RLock()
+	defer s.cfgMutex.RUnlock()
+
+	numbers := responseNumbers(s.cfg.Routes)
+	counts := make(map[string]int)
+	for _, route := range s.cfg.Routes {
+		counts[route.Path]++
+	}
+
+	routes := make([]routeState, 0, len(s.cfg.Routes))
+	for i, route := range s.cfg.Routes {
+		pinned, ok := s.pinnedResponse(route.Path)
+		routes = append(routes, routeState{
+			Path:          route.Path,
+			Type:          route.Type,
+			Status:        route.StatusCode,
+			ContentType:   route.ContentType,
+			Enabled:       s.routeEnabled(route),
+			Response:      numbers[i],
+			Responses:     counts[route.Path],
+			RequiredState: route.RequiredState,
+			Active:        ok && pinned == numbers[i],
+		})
+	}
+
+	writeJSON(rw, http.StatusOK, routes)
+}
+
+func (s *Server) handleSetRouteEnabled(rw http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path    string `json:"path"`
+		Enabled bool   `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	if !s.hasRoute(req.Path) {
+		writeJSONError(rw, http.StatusNotFound, errUnknownRoute)
+		return
+	}
+
+	if req.Enabled {
+		s.disabledRoutes.Delete(req.Path)
+	} else {
+		s.disabledRoutes.Store(req.Path, struct{}{})
+	}
+	s.logger.Info("Route toggled", "path", req.Path, "enabled", req.Enabled)
+
+	s.handleGetRoutes(rw, r)
+}
+
+// handleSetRouteResponse pins the response of a path, so that it answers
+// whatever the priorities and scenario states. A null response unpins it.
+func (s *Server) handleSetRouteResponse(rw http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path     string `json:"path"`
+		Response *int   `json:"response"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	responses := s.routeResponses(req.Path)
+	if responses == 0 {
+		writeJSONError(rw, http.StatusNotFound, errUnknownRoute)
+		return
+	}
+
+	if req.Response == nil {
+		s.pinnedResponses.Delete(req.Path)
+		s.logger.Info("Route response unpinned", "path", req.Path)
+	} else {
+		if *req.Response < 0 || *req.Response >= responses {
+			writeJSONError(rw, http.StatusBadRequest, errUnknownResponse)
+			return
+		}
+
+		s.pinnedResponses.Store(req.Path, *req.Response)
+		s.logger.Info("Route response pinned", "path", req.Path, "response", *req.Response)
+	}
+
+	s.handleGetRoutes(rw, r)
+}
+
+func (s *Server) hasRoute(path string) bool {
+	return s.routeResponses(path) > 0
+}
+
+// routeResponses counts the routes of a path.
+func (s *Server) routeResponses(path string) int {
+	s.cfgMutex.RLock()
+	defer s.cfgMutex.RUnlock()
+
+	var n int
+	for _, route := range s.cfg.Routes {
+		if route.Path == path {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (s *Server) handleGetReload(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, http.StatusOK, s.activity.lastReload())
+}
+
+// handleActivity streams requests and reloads as server-sent events.
+func (s *Server) handleActivity(rw http.ResponseWriter, r *http.Request) {
+	w, err := newEventWriter(rw)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	ch := s.activity.subscribe()
+	defer s.activity.unsubscribe(ch)
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	w.flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-ch:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				s.logger.Error("Failed to encode activity", "err", err)
+				continue
+			}
+
+			if err := w.WriteEvent(conf.EventConfig{Event: ev.Type, Data: string(data)}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// captureActivity starts capturing the request for the dashboard. The
+// returned function publishes it once the response is written.
+func (s *Server) captureActivity(route conf.RouteConfig, reqID uint64, rw *statusRecorder, r *http.Request) func(status int, took time.Duration) {
+	if !s.activity.watched() {
+		return func(int, time.Duration) {}
+	}
+
+	body, err := peekBodyPrefix(r, dashboardBodyLimit)
+	if err != nil {
+		s.logger.Error("Failed to read request body", "err", err)
+	}
+	rw.captureBody(dashboardBodyLimit)
+
+	req := &requestActivity{
+		ID:      reqID,
+		Time:    time.Now(),
+		Route:   route.Path,
+		Method:  r.Method,
+		URL:     r.URL.String(),
+		Headers: recording.Redact(r.Header),
+		Body:    string(body),
+	}
+
+	return func(status int, took time.Duration) {
+		req.Status = status
+		req.ResponseHeaders = recording.Redact(rw.Header())
+		req.ResponseBody = truncate(rw.capturedBody(), dashboardBodyLimit)
+		req.Took = float64(took.Microseconds()) / 1000
+
+		s.activity.publish(activityEvent{Type: "request", Request: req})
+	}
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+
+	return s[:limit]
+}
@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/negotiate"
+)
+
+// errNotAcceptableEncoding means the client refuses identity and every
+// encoding the route offers.
+var errNotAcceptableEncoding = errors.New("no acceptable content encoding")
+
+// withEncoding buffers the response to answer conditional requests and
+// compress the body. Routes without a last_modified were last modified
+// when the configuration was loaded.
+func (s *Server) withEncoding(route conf.RouteConfig, respond responder) responder {
+	conditional := route.Conditional != nil && *route.Conditional
+
+	lastModified := route.LastModified
+	if lastModified.IsZero() {
+		lastModified = s.clock.Now()
+	}
+	lastModified = lastModified.UTC().Truncate(time.Second)
+
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		buf := newBufferedWriter(rw)
+		if err := respond(buf, r); err != nil {
+			return err
+		}
+
+		status, body := buf.status, buf.body.Bytes()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		encoding := ""
+		if route.Compression != nil {
+			var err error
+			encoding, body, err = compressBody(rw, r, route.Compression, body)
+			if errors.Is(err, errNotAcceptableEncoding) {
+				writeJSON(rw, http.StatusNotAcceptable, map[string]any{
+					"error":     "not_acceptable",
+					"available": route.Compression.Encodings,
+				})
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("compress body: %w", err)
+			}
+		}
+
+		if conditional && status == http.StatusOK {
+			etag := bodyETag(buf.body.Bytes(), encoding)
+			rw.Header().Set("ETag", etag)
+			rw.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+			if notModified(r, etag, lastModified) {
+				for _, h := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+					rw.Header().Del(h)
+				}
+				rw.WriteHeader(http.StatusNotModified)
+				return nil
+			}
+		}
+
+		if sr, ok := rw.(*statusRecorder); ok && encoding != "" {
+			sr.captureEncoded(buf.body.Bytes())
+		}
+
+		rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		rw.WriteHeader(status)
+
+		if _, err := rw.Write(body); err != nil {
+			return fmt.Errorf("write body: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// compressBody encodes the body as the client prefers and sets the
+// headers to match. It returns the encoding used, empty for identity.
+func compressBody(rw http.ResponseWriter, r *http.Request, cfg *conf.CompressionConfig, body []byte) (string, []byte, error) {
+	rw.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := cfg.Force
+	if encoding == "" {
+		acceptEncoding := r.Header.Get("Accept-Encoding")
+
+		// Small bodies are not worth compressing, unless the client
+		// refuses them as they are.
+		if _, identityOK := negotiate.Encoding(acceptEncoding, nil); identityOK && len(body) < cfg.MinSize {
+			return "", body, nil
+		}
+
+		var ok bool
+		if encoding, ok = negotiate.Encoding(acceptEncoding, cfg.Encodings); !ok {
+			return "", nil, errNotAcceptableEncoding
+		}
+	}
+
+	if encoding == conf.EncodingIdentity {
+		encoding = ""
+	}
+
+	if encoding != "" {
+		var err error
+		if body, err = compress(encoding, body); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if header := cmp.Or(cfg.ContentEncoding, encoding); header != "" {
+		rw.Header().Set("Content-Encoding", header)
+	}
+
+	return encoding, body, nil
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch encoding {
+	case conf.EncodingGzip:
+		w = gzip.NewWriter(&buf)
+	case conf.EncodingDeflate:
+		// HTTP deflate is the zlib format.
+		w = zlib.NewWriter(&buf)
+	case conf.EncodingBrotli:
+		w = brotli.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// bodyETag hashes the uncompressed body. Compressed representations get
+// the encoding appended, since they are different bytes.
+func bodyETag(body []byte, encoding string) string {
+	sum := sha256.Sum256(body)
+	tag := base64.RawURLEncoding.EncodeToString(sum[:12])
+	if encoding != "" {
+		tag += "-" + encoding
+	}
+
+	return strconv.Quote(tag)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since without it,
+// as RFC 9110 asks.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+
+	return err == nil && !lastModified.After(since)
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kaato137/quickrest/internal/pkg/filewatch"
+	"github.com/kaato137/quickrest/internal/pkg/fixture"
+)
+
+// fixtureSet holds the loaded fixtures. Scripts and templates get the
+// same data on every request, so they must not modify it.
+type fixtureSet struct {
+	mutex sync.RWMutex
+	data  map[string]any
+
+	stopWatching func()
+}
+
+func (f *fixtureSet) all() map[string]any {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.data
+}
+
+func (f *fixtureSet) replace(data map[string]any) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.data = data
+}
+
+// update swaps a single fixture, copying the map so that readers keep a
+// consistent set.
+func (f *fixtureSet) update(name string, value any) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data := make(map[string]any, len(f.data))
+	for k, v := range f.data {
+		data[k] = v
+	}
+	data[name] = value
+
+	f.data = data
+}
+
+func (s *Server) loadFixtures() (map[string]any, error) {
+	data := make(map[string]any, len(s.cfg.Fixtures))
+	for name, path := range s.cfg.Fixtures {
+		value, err := fixture.Load(s.cfg.ResolvePath(path))
+		if err != nil {
+			return nil, fmt.Errorf("fixture %q: %w", name, err)
+		}
+		data[name] = value
+	}
+
+	return data, nil
+}
+
+// watchFixtures reloads each fixture when its file changes. A fixture
+// that fails to load keeps its previous data. Watchers of the previous
+// configuration are stopped.
+func (s *Server) watchFixtures() error {
+	if s.fixtures.stopWatching != nil {
+		s.fixtures.stopWatching()
+		s.fixtures.stopWatching = nil
+	}
+
+	var closers []func()
+	stop := func() {
+		for _, closeFn := range closers {
+			closeFn()
+		}
+	}
+
+	for name, path := range s.cfg.Fixtures {
+		path := s.cfg.ResolvePath(path)
+
+		closer, err := filewatch.WatchFilePath(path).
+			WithInterval(s.cfg.ReloadInterval).
+			OnChange(func() error {
+				value, err := fixture.Load(path)
+				if err != nil {
+					return err
+				}
+
+				s.fixtures.update(name, value)
+				s.logger.Info("Fixture reloaded", "name", name)
+
+				return nil
+			}).
+			OnError(func(err error) bool {
+				s.logger.Error("Error on fixture reload. Keeping old data", "name", name, "err", err)
+				return true
+			}).
+			Run(context.Background())
+		if err != nil {
+			stop()
+			return fmt.Errorf("watch fixture %q: %w", name, err)
+		}
+
+		closers = append(closers, closer)
+	}
+
+	s.fixtures.stopWatching = stop
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/gqlmock"
+)
+
+func (s *Server) graphQLResponder(route conf.RouteConfig) (responder, error) {
+	schemaPath := s.cfg.ResolvePath(route.GraphQL.Schema)
+
+	sdl, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+
+	schema, err := gqlmock.LoadSchema(schemaPath, string(sdl))
+	if err != nil {
+		return nil, fmt.Errorf("load schema: %w", err)
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		req, err := decodeGraphQLRequest(r)
+		if err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]any{
+				"errors": []map[string]string{{"message": err.Error()}},
+			})
+			return nil
+		}
+
+		executor := gqlmock.NewExecutor(schema).
+			WithSeed(s.seed).
+			WithResolver(s.graphQLResolver(route, r))
+		if route.GraphQL.ListLength > 0 {
+			executor.WithListLength(route.GraphQL.ListLength)
+		}
+
+		resp := executor.Execute(req)
+
+		rw.WriteHeader(route.StatusCode)
+		if err := json.NewEncoder(rw).Encode(resp); err != nil {
+			return fmt.Errorf("write body: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+// graphQLResolver answers fields that have a configured resolver, either
+// with its static value or with the result of its script. Scripts see the
+// field arguments as `args` and the parent object as `parent`.
+func (s *Server) graphQLResolver(route conf.RouteConfig, r *http.Request) gqlmock.ResolveFunc {
+	var renderer *Renderer
+
+	return func(field gqlmock.FieldContext) (any, bool, error) {
+		resolver, ok := route.GraphQL.Resolvers[field.TypeName+"."+field.FieldName]
+		if !ok {
+			return nil, false, nil
+		}
+
+		if resolver.JS == "" {
+			return resolver.Value, true, nil
+		}
+
+		if renderer == nil {
+			renderer = s.newRenderer(requestKeys(route, r)...)
+			seedFaker(renderer, route, r)
+		}
+
+		if err := renderer.Set("args", field.Args); err != nil {
+			return nil, false, err
+		}
+
+		if err := renderer.Set("parent", field.Parent); err != nil {
+			return nil, false, err
+		}
+
+		val, err := renderer.Eval(resolver.JS, prepareRenderContext(route, r))
+		if err != nil {
+			return nil, false, err
+		}
+
+		// Round trip through JSON so that lists and objects come back as
+		// []any and map[string]any regardless of how otto exports them.
+		encoded, err := encodeValue(val)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var value any
+		if err := json.Unmarshal(encoded, &value); err != nil {
+			value = string(encoded)
+		}
+
+		return value, true, nil
+	}
+}
+
+func decodeGraphQLRequest(r *http.Request) (gqlmock.Request, error) {
+	var req gqlmock.Request
+
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+
+		if vars := q.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				return req, fmt.Errorf("decode variables: %w", err)
+			}
+		}
+
+		return req, nil
+	}
+
+	body, err := peekBody(r)
+	if err != nil {
+		return req, fmt.Errorf("read body: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return req, fmt.Errorf("decode request: %w", err)
+	}
+
+	return req, nil
+}
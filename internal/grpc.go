@@ -0,0 +1,469 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/protoload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcState is everything the gRPC handler needs. It is swapped as a whole
+// on config reload.
+type grpcState struct {
+	registry *protoregistry.Files
+	methods  map[string]protoreflect.MethodDescriptor
+	configs  map[string]grpcMethod
+}
+
+type grpcMethod struct {
+	conf.GRPCMethodConfig
+
+	code codes.Code
+}
+
+func (s *Server) setupGRPC() error {
+	if s.cfg.GRPC == nil {
+		return nil
+	}
+
+	state, err := s.loadGRPCState(s.cfg.GRPC)
+	if err != nil {
+		return err
+	}
+	s.grpcState.Store(state)
+
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(s.handleGRPC))
+
+	if s.cfg.GRPC.Reflection {
+		refl := reflection.NewServerV1(reflection.ServerOptions{
+			Services:           grpcServices{&s.grpcState},
+			DescriptorResolver: grpcResolver{&s.grpcState},
+		})
+		reflectionv1.RegisterServerReflectionServer(srv, refl)
+		reflectionv1alpha.RegisterServerReflectionServer(srv, reflection.NewServer(reflection.ServerOptions{
+			Services:           grpcServices{&s.grpcState},
+			DescriptorResolver: grpcResolver{&s.grpcState},
+		}))
+	}
+
+	lis, err := net.Listen("tcp", s.cfg.GRPC.Address)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	s.grpcAddr = s.cfg.GRPC.Address
+
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			s.logger.Error("gRPC server stopped", "err", err)
+		}
+	}()
+	s.appendCloser(srv.Stop)
+
+	s.logger.Info("gRPC listen on", "addr", s.cfg.GRPC.Address, "methods", len(state.methods))
+
+	return nil
+}
+
+func (s *Server) reloadGRPC() error {
+	if s.cfg.GRPC == nil {
+		return nil
+	}
+
+	// The listener is only started at boot.
+	if s.grpcState.Load() == nil {
+		s.logger.Warn("gRPC was added to the config, restart to serve it", "addr", s.cfg.GRPC.Address)
+		return nil
+	}
+
+	if s.cfg.GRPC.Address != s.grpcAddr {
+		s.logger.Warn("gRPC address changed, restart to listen on it", "addr", s.cfg.GRPC.Address, "listening", s.grpcAddr)
+	}
+
+	state, err := s.loadGRPCState(s.cfg.GRPC)
+	if err != nil {
+		return fmt.Errorf("load grpc: %w", err)
+	}
+	s.grpcState.Store(state)
+
+	return nil
+}
+
+func (s *Server) loadGRPCState(cfg *conf.GRPCConfig) (*grpcState, error) {
+	var (
+		registry *protoregistry.Files
+		err      error
+	)
+	if cfg.DescriptorSet != "" {
+		registry, err = protoload.FromDescriptorSet(s.cfg.ResolvePath(cfg.DescriptorSet))
+	} else {
+		// Protos are paths relative to the config, or names relative to
+		// an import path.
+		protos := make([]string, 0, len(cfg.Protos))
+		for _, p := range cfg.Protos {
+			if _, err := os.Stat(s.cfg.ResolvePath(p)); err == nil {
+				p = s.cfg.ResolvePath(p)
+			}
+			protos = append(protos, p)
+		}
+
+		importPaths := make([]string, 0, len(cfg.ImportPaths))
+		for _, p := range cfg.ImportPaths {
+			importPaths = append(importPaths, s.cfg.ResolvePath(p))
+		}
+
+		registry, err = protoload.FromProtoFiles(context.Background(), importPaths, protos)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &grpcState{
+		registry: registry,
+		methods:  protoload.Methods(registry),
+		configs:  make(map[string]grpcMethod),
+	}
+
+	for _, m := range cfg.Methods {
+		if _, ok := state.methods[m.Method]; !ok {
+			return nil, fmt.Errorf("method %q is not defined in protos", m.Method)
+		}
+
+		code, err := parseGRPCCode(m.Code)
+		if err != nil {
+			return nil, fmt.Errorf("method %q: %w", m.Method, err)
+		}
+
+		state.configs[m.Method] = grpcMethod{GRPCMethodConfig: m, code: code}
+	}
+
+	return state, nil
+}
+
+func (s *Server) handleGRPC(_ any, stream grpc.ServerStream) (err error) {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+
+	state := s.grpcState.Load()
+	md, ok := state.methods[fullMethod]
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+	}
+	method := state.configs[fullMethod]
+
+	now := time.Now()
+	reqID := s.ReqID()
+	s.logger.Info("gRPC call started", "id", reqID, "method", fullMethod)
+	defer func(now time.Time) {
+		took := time.Since(now)
+		code := status.Code(err)
+
+		s.logger.Info("gRPC call ended", "id", reqID, "took", took, "code", code)
+		s.metrics.observeGRPCCall(fullMethod, code.String(), took)
+	}(now)
+
+	if method.Latency > 0 || method.Jitter > 0 {
+		if err := sleepContext(stream.Context(), calcWaitDuration(s.random, method.Latency, method.Jitter)); err != nil {
+			return status.FromContextError(err).Err()
+		}
+	}
+
+	if len(method.Headers) > 0 {
+		if err := stream.SendHeader(metadata.New(method.Headers)); err != nil {
+			return err
+		}
+	}
+
+	if len(method.Trailers) > 0 {
+		stream.SetTrailer(metadata.New(method.Trailers))
+	}
+
+	call := &grpcCall{server: s, stream: stream, md: md, method: method}
+
+	if err := call.serve(); err != nil {
+		s.logger.Error("Failed to serve gRPC call", "err", err)
+		return err
+	}
+
+	if method.Record {
+		if err := s.reqRecorder.RecordMessage(formatGRPCFilename(fullMethod), fullMethod, call.recorded()); err != nil {
+			s.metrics.recorderErrors.Inc()
+			s.logger.Error("Failed to record request", "err", err)
+		}
+	}
+
+	if method.code != codes.OK {
+		return status.Error(method.code, method.Message)
+	}
+
+	return nil
+}
+
+type grpcCall struct {
+	server *Server
+	stream grpc.ServerStream
+	md     protoreflect.MethodDescriptor
+	method grpcMethod
+
+	requests []map[string]any
+	// sent counts the responses sent, which tells stream items apart when
+	// seeding their scripts.
+	sent int
+}
+
+func (c *grpcCall) serve() error {
+	switch {
+	case c.md.IsStreamingClient() && c.md.IsStreamingServer():
+		// Bidirectional streams answer every incoming message.
+		for {
+			if err := c.receive(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+
+			if err := c.respond(); err != nil {
+				return err
+			}
+		}
+	case c.md.IsStreamingClient():
+		for {
+			if err := c.receive(); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+		}
+	default:
+		if err := c.receive(); err != nil {
+			return err
+		}
+	}
+
+	return c.respond()
+}
+
+func (c *grpcCall) receive() error {
+	msg := dynamicpb.NewMessage(c.md.Input())
+	if err := c.stream.RecvMsg(msg); err != nil {
+		return err
+	}
+
+	encoded, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return fmt.Errorf("decode request: %w", err)
+	}
+
+	c.requests = append(c.requests, decoded)
+
+	return nil
+}
+
+func (c *grpcCall) respond() error {
+	if c.method.code != codes.OK {
+		return nil
+	}
+
+	if !c.md.IsStreamingServer() || len(c.method.Stream) == 0 {
+		return c.send(c.method.Response)
+	}
+
+	for i, item := range c.method.Stream {
+		if i > 0 {
+			if err := sleepContext(c.stream.Context(), c.method.StreamInterval); err != nil {
+				return status.FromContextError(err).Err()
+			}
+		}
+
+		if err := c.send(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *grpcCall) send(template any) error {
+	data, err := c.render(template)
+	if err != nil {
+		return fmt.Errorf("render response: %w", err)
+	}
+
+	msg := dynamicpb.NewMessage(c.md.Output())
+	if len(data) > 0 {
+		if err := protojson.Unmarshal(data, msg); err != nil {
+			return fmt.Errorf("convert response to %s: %w", c.md.Output().FullName(), err)
+		}
+	}
+
+	c.sent++
+
+	return c.stream.SendMsg(msg)
+}
+
+// render produces the JSON of a response message. Static responses may
+// refer to top-level request fields with {field} placeholders, scripts see
+// the last request as `request`, all of them as `requests` and the
+// configured response or stream item being sent as `item`.
+func (c *grpcCall) render(template any) ([]byte, error) {
+	var request map[string]any
+	if len(c.requests) > 0 {
+		request = c.requests[len(c.requests)-1]
+	}
+
+	if c.method.ResponseJS != "" {
+		renderer := c.server.newRenderer(string(c.md.FullName()), fmt.Sprint(c.requests), strconv.Itoa(c.sent))
+		if err := renderer.Set("request", request); err != nil {
+			return nil, err
+		}
+
+		if err := renderer.Set("requests", c.requests); err != nil {
+			return nil, err
+		}
+
+		if err := renderer.Set("item", template); err != nil {
+			return nil, err
+		}
+
+		val, err := renderer.Eval(c.method.ResponseJS, RenderContext{})
+		if err != nil {
+			return nil, err
+		}
+
+		return encodeValue(val)
+	}
+
+	var text string
+	switch t := template.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		text = t
+	default:
+		encoded, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		text = string(encoded)
+	}
+
+	for k, v := range request {
+		switch v.(type) {
+		case map[string]any, []any:
+			continue
+		}
+		text = strings.ReplaceAll(text, fmt.Sprintf("{%s}", k), fmt.Sprint(v))
+	}
+
+	return []byte(text), nil
+}
+
+func (c *grpcCall) recorded() []byte {
+	var b strings.Builder
+	for _, req := range c.requests {
+		encoded, err := json.Marshal(req)
+		if err != nil {
+			continue
+		}
+		b.Write(encoded)
+		b.WriteString("\n")
+	}
+
+	return []byte(b.String())
+}
+
+// parseGRPCCode accepts both numeric codes and their names, such as
+// "NOT_FOUND" or "not_found".
+func parseGRPCCode(raw string) (codes.Code, error) {
+	if raw == "" {
+		return codes.OK, nil
+	}
+
+	if _, err := strconv.Atoi(raw); err != nil {
+		raw = strconv.Quote(strings.ToUpper(raw))
+	}
+
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(raw)); err != nil {
+		return codes.OK, err
+	}
+
+	return code, nil
+}
+
+func formatGRPCFilename(fullMethod string) string {
+	date := time.Now().Format("2006-01-02")
+	name := strings.ReplaceAll(strings.TrimPrefix(fullMethod, "/"), "/", " ")
+
+	return fmt.Sprintf("grpc %s-%s.log", name, date)
+}
+
+// grpcServices lists the loaded services to the reflection service.
+type grpcServices struct {
+	state *atomic.Pointer[grpcState]
+}
+
+func (g grpcServices) GetServiceInfo() map[string]grpc.ServiceInfo {
+	info := make(map[string]grpc.ServiceInfo)
+	for _, md := range g.state.Load().methods {
+		svc := string(md.Parent().FullName())
+		entry := info[svc]
+		entry.Methods = append(entry.Methods, grpc.MethodInfo{
+			Name:           string(md.Name()),
+			IsClientStream: md.IsStreamingClient(),
+			IsServerStream: md.IsStreamingServer(),
+		})
+		info[svc] = entry
+	}
+
+	return info
+}
+
+// grpcResolver looks descriptors up in the loaded protos first and falls
+// back to the ones compiled into the binary, such as the reflection service.
+type grpcResolver struct {
+	state *atomic.Pointer[grpcState]
+}
+
+func (g grpcResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := g.state.Load().registry.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (g grpcResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := g.state.Load().registry.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
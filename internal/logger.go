@@ -1,9 +1,17 @@
 package internal
 
 import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/kaato137/quickrest/internal/conf"
 )
 
 type Logger interface {
@@ -17,20 +25,116 @@ type Logger interface {
 	Errorf(format string, keyvals ...any)
 	Fatal(msg any, keyvals ...any)
 	Fatalf(format string, keyvals ...any)
+	// Access writes a line of the access log as it is, without a level or
+	// timestamp, whatever the level.
+	Access(line string)
 }
 
 type CustomLogger struct {
 	logger *log.Logger
+
+	mutex sync.Mutex
+	// base is written to when no log file is set, and out is the writer
+	// in use.
+	base io.Writer
+	out  io.Writer
+	file *os.File
+	path string
+}
+
+func NewLogger(cfg conf.LogConfig) (*CustomLogger, error) {
+	l := NewLoggerTo(os.Stderr)
+	if err := l.Apply(cfg); err != nil {
+		return nil, err
+	}
+
+	return l, nil
 }
 
-func NewLogger() *CustomLogger {
+// NewLoggerTo creates a text logger writing to w.
+func NewLoggerTo(w io.Writer) *CustomLogger {
 	return &CustomLogger{
-		logger: log.NewWithOptions(os.Stderr, log.Options{
+		logger: log.NewWithOptions(w, log.Options{
 			ReportTimestamp: true,
 		}),
+		base: w,
+		out:  w,
 	}
 }
 
+// Apply switches to the format, level and file of cfg, so that they can
+// change with a reloaded config. Nothing changes when cfg is invalid.
+func (l *CustomLogger) Apply(cfg conf.LogConfig) error {
+	var formatter log.Formatter
+	switch cfg.Format {
+	case "", conf.LogFormatText:
+		formatter = log.TextFormatter
+	case conf.LogFormatJSON:
+		formatter = log.JSONFormatter
+	case conf.LogFormatLogfmt:
+		formatter = log.LogfmtFormatter
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	level := log.InfoLevel
+	if cfg.Level != "" {
+		var err error
+		if level, err = log.ParseLevel(cfg.Level); err != nil {
+			return fmt.Errorf("parse log level: %w", err)
+		}
+	}
+
+	switch cfg.Access {
+	case "", conf.AccessLogCombined:
+	default:
+		return fmt.Errorf("unknown access log format %q", cfg.Access)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if cfg.File != l.path {
+		out, file := l.base, (*os.File)(nil)
+		if cfg.File != "" {
+			var err error
+			file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				return fmt.Errorf("open log file: %w", err)
+			}
+			out = file
+		}
+
+		l.logger.SetOutput(out)
+		if l.file != nil {
+			l.file.Close()
+		}
+		l.out, l.file, l.path = out, file, cfg.File
+	}
+
+	l.logger.SetFormatter(formatter)
+	l.logger.SetLevel(level)
+
+	return nil
+}
+
+// Close closes the log file if the logger writes to one.
+func (l *CustomLogger) Close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.file != nil {
+		l.file.Close()
+	}
+}
+
+func (l *CustomLogger) Access(line string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, _ = io.WriteString(l.out, line+"\n")
+}
+
 func (l *CustomLogger) Debug(msg any, keyvals ...any) {
 	l.logger.Debug(msg, keyvals...)
 }
@@ -70,3 +174,40 @@ func (l *CustomLogger) Fatal(msg any, keyvals ...any) {
 func (l *CustomLogger) Fatalf(format string, keyvals ...any) {
 	l.logger.Fatalf(format, keyvals...)
 }
+
+// formatAccessLine formats a request in the Apache/nginx combined log format.
+func formatAccessLine(r *http.Request, started time.Time, status, size int) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	user := "-"
+	if name, _, ok := r.BasicAuth(); ok && name != "" {
+		user = name
+	}
+
+	bytes := "-"
+	if size > 0 {
+		bytes = strconv.Itoa(size)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] %q %d %s %q %q",
+		host,
+		user,
+		started.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method+" "+r.URL.RequestURI()+" "+r.Proto,
+		status,
+		bytes,
+		orDash(r.Referer()),
+		orDash(r.UserAgent()),
+	)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
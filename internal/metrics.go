@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	renderErrors    *prometheus.CounterVec
+	jsDuration      *prometheus.HistogramVec
+	reloads         *prometheus.CounterVec
+	recorderErrors  prometheus.Counter
+	callbacks       *prometheus.CounterVec
+	grpcCalls       *prometheus.CounterVec
+	grpcDuration    *prometheus.HistogramVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickrest_http_requests_total",
+			Help: "Number of handled requests.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "quickrest_http_request_duration_seconds",
+			Help:    "Time spent on requests, including simulated latency.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "code"}),
+		renderErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickrest_render_errors_total",
+			Help: "Number of responses that failed to render.",
+		}, []string{"route"}),
+		jsDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "quickrest_js_execution_seconds",
+			Help:    "Time spent executing body_js scripts.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"route"}),
+		reloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickrest_config_reloads_total",
+			Help: "Number of configuration reloads by result.",
+		}, []string{"result"}),
+		recorderErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "quickrest_recorder_write_errors_total",
+			Help: "Number of requests that failed to be recorded.",
+		}),
+		callbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickrest_callbacks_total",
+			Help: "Number of callbacks by result, after retries.",
+		}, []string{"route", "result"}),
+		grpcCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickrest_grpc_calls_total",
+			Help: "Number of handled gRPC calls.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "quickrest_grpc_call_duration_seconds",
+			Help:    "Time spent on gRPC calls, including simulated latency.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.renderErrors,
+		m.jsDuration,
+		m.reloads,
+		m.recorderErrors,
+		m.callbacks,
+		m.grpcCalls,
+		m.grpcDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+func (m *metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *metrics) observeRequest(route, method string, status int, took time.Duration) {
+	code := strconv.Itoa(status)
+
+	m.requests.WithLabelValues(route, method, code).Inc()
+	m.requestDuration.WithLabelValues(route, method, code).Observe(took.Seconds())
+}
+
+func (m *metrics) observeGRPCCall(method, code string, took time.Duration) {
+	m.grpcCalls.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method, code).Observe(took.Seconds())
+}
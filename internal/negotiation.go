@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/negotiate"
+)
+
+// negotiatedResponder answers with the body of the media type the Accept
+// header prefers, or with 406 when the route has none of them.
+func (s *Server) negotiatedResponder(route conf.RouteConfig) responder {
+	offers := bodyMediaTypes(route)
+
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		rw.Header().Add("Vary", "Accept")
+
+		mediaType, ok := negotiate.Select(r.Header.Get("Accept"), offers)
+		if !ok {
+			writeJSON(rw, http.StatusNotAcceptable, map[string]any{
+				"error":     "not_acceptable",
+				"available": offers,
+			})
+			return nil
+		}
+
+		rw.Header().Set("Content-Type", mediaType)
+		rw.WriteHeader(route.StatusCode)
+
+		if _, err := rw.Write([]byte(resolveWildcards(route.Bodies[mediaType], route.Wildcards(), r))); err != nil {
+			return fmt.Errorf("write body: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// bodyMediaTypes orders the media types of a route for negotiation. The
+// content type of the route comes first, so that it is the default.
+func bodyMediaTypes(route conf.RouteConfig) []string {
+	offers := make([]string, 0, len(route.Bodies))
+	for mediaType := range route.Bodies {
+		offers = append(offers, mediaType)
+	}
+	slices.Sort(offers)
+
+	if i := slices.Index(offers, route.ContentType); i > 0 {
+		offers = append([]string{route.ContentType}, slices.Delete(offers, i, i+1)...)
+	}
+
+	return offers
+}
@@ -0,0 +1,611 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kaato137/quickrest/internal/conf"
+)
+
+const oidcCodeTTL = 10 * time.Minute
+
+var errOIDCNotConfigured = errors.New("oidc provider is not configured")
+
+// oidcProvider keeps what must survive config reloads: the signing key
+// and the codes and refresh tokens handed out.
+type oidcProvider struct {
+	key *rsa.PrivateKey
+	kid string
+
+	mutex         sync.Mutex
+	codes         map[string]oidcGrant
+	refreshTokens map[string]oidcGrant
+}
+
+type oidcGrant struct {
+	clientID    string
+	redirectURI string
+	username    string
+	scope       string
+	nonce       string
+	challenge   string
+	method      string
+	authTime    time.Time
+	expires     time.Time
+}
+
+// oauthError is an error response of RFC 6749.
+type oauthError struct {
+	status      int
+	code        string
+	description string
+}
+
+func newOIDCProvider() (*oidcProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+
+	sum := sha256.Sum256(key.N.Bytes())
+
+	return &oidcProvider{
+		key:           key,
+		kid:           hex.EncodeToString(sum[:8]),
+		codes:         make(map[string]oidcGrant),
+		refreshTokens: make(map[string]oidcGrant),
+	}, nil
+}
+
+// jwks returns the public key set of the provider.
+func (p *oidcProvider) jwks() map[string]any {
+	return map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": p.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	}
+}
+
+func (p *oidcProvider) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+
+	return token.SignedString(p.key)
+}
+
+func (p *oidcProvider) storeCode(code string, grant oidcGrant) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.codes[code] = grant
+}
+
+// takeCode returns the grant of a code. Codes can be used only once.
+func (p *oidcProvider) takeCode(code string) (oidcGrant, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	grant, ok := p.codes[code]
+	delete(p.codes, code)
+
+	return grant, ok
+}
+
+func (p *oidcProvider) storeRefreshToken(token string, grant oidcGrant) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.refreshTokens[token] = grant
+}
+
+// takeRefreshToken returns the grant of a refresh token. Refresh tokens
+// are rotated, so each one can be used only once.
+func (p *oidcProvider) takeRefreshToken(token string) (oidcGrant, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	grant, ok := p.refreshTokens[token]
+	delete(p.refreshTokens, token)
+
+	return grant, ok
+}
+
+// registerOIDC adds the provider endpoints to the router. They share the
+// top-level CORS, so that single-page apps can call them from a browser.
+func (s *Server) registerOIDC(rt *router, cfg *conf.OIDCConfig) error {
+	if s.oidc == nil {
+		provider, err := newOIDCProvider()
+		if err != nil {
+			return err
+		}
+		s.oidc = provider
+	}
+
+	endpoints := map[string]responder{
+		"GET /.well-known/openid-configuration": s.oidcDiscovery(cfg),
+		"GET /jwks":                             s.oidcJWKS,
+		"GET /authorize":                        s.oidcAuthorize(cfg),
+		"POST /authorize":                       s.oidcAuthorize(cfg),
+		"POST /token":                           s.oidcToken(cfg),
+		"GET /userinfo":                         s.oidcUserInfo(cfg),
+		"POST /userinfo":                        s.oidcUserInfo(cfg),
+	}
+
+	for pattern, respond := range endpoints {
+		method, path, _ := strings.Cut(pattern, " ")
+		route := conf.RouteConfig{
+			Path:        method + " " + strings.TrimSuffix(cfg.Path, "/") + path,
+			ContentType: "application/json",
+			CORS:        s.cfg.CORS,
+		}
+
+		rt.mux.HandleFunc(route.Path, s.handleResponse(route, respond))
+		rt.routes[route.Path] = route
+	}
+
+	return nil
+}
+
+func oidcIssuer(cfg *conf.OIDCConfig, r *http.Request) string {
+	if cfg.Issuer != "" {
+		return strings.TrimSuffix(cfg.Issuer, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + strings.TrimSuffix(cfg.Path, "/")
+}
+
+func (s *Server) oidcDiscovery(cfg *conf.OIDCConfig) responder {
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		issuer := oidcIssuer(cfg, r)
+
+		writeJSON(rw, http.StatusOK, map[string]any{
+			"issuer":                                issuer,
+			"authorization_endpoint":                issuer + "/authorize",
+			"token_endpoint":                        issuer + "/token",
+			"userinfo_endpoint":                     issuer + "/userinfo",
+			"jwks_uri":                              issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"grant_types_supported":                 []string{"authorization_code", "client_credentials", "refresh_token"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"scopes_supported":                      []string{"openid", "profile", "email", "offline_access"},
+			"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+			"code_challenge_methods_supported":      []string{"S256", "plain"},
+		})
+
+		return nil
+	}
+}
+
+func (s *Server) oidcJWKS(rw http.ResponseWriter, r *http.Request) error {
+	writeJSON(rw, http.StatusOK, s.oidc.jwks())
+	return nil
+}
+
+var oidcLoginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Sign in</title>
+<style>
+  body { font-family: system-ui, sans-serif; display: flex; justify-content: center; margin-top: 10vh; }
+  form { border: 1px solid #d0d7de; border-radius: 6px; padding: 24px; width: 280px; }
+  label, input, select, button { display: block; width: 100%; box-sizing: border-box; margin-bottom: 12px; }
+  .error { color: #cf222e; }
+</style>
+</head>
+<body>
+<form method="post">
+  <h2>Sign in to {{ .Client }}</h2>
+  {{ with .Error }}<p class="error">{{ . }}</p>{{ end }}
+  <label>User
+    <select name="username">
+      {{ range .Users }}<option>{{ .Username }}</option>{{ end }}
+    </select>
+  </label>
+  <label>Password <input type="password" name="password"></label>
+  <button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+func (s *Server) oidcAuthorize(cfg *conf.OIDCConfig) responder {
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+
+		client, err := oidcClient(cfg, q.Get("client_id"), q.Get("redirect_uri"))
+		if err != nil {
+			// Without a trustworthy redirect URI the error can only be
+			// shown to the user.
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return nil
+		}
+
+		redirect := func(params url.Values) {
+			target, _ := url.Parse(q.Get("redirect_uri"))
+			query := target.Query()
+			for k, v := range params {
+				query[k] = v
+			}
+			if state := q.Get("state"); state != "" {
+				query.Set("state", state)
+			}
+			target.RawQuery = query.Encode()
+
+			http.Redirect(rw, r, target.String(), http.StatusFound)
+		}
+
+		if q.Get("response_type") != "code" {
+			redirect(url.Values{"error": {"unsupported_response_type"}})
+			return nil
+		}
+
+		var user *conf.OIDCUserConfig
+		if cfg.Login {
+			if r.Method == http.MethodGet {
+				return renderLoginPage(rw, cfg, client, "")
+			}
+
+			user = oidcLogin(cfg, r.PostFormValue("username"), r.PostFormValue("password"))
+			if user == nil {
+				return renderLoginPage(rw, cfg, client, "Invalid user name or password")
+			}
+		} else {
+			user = oidcDefaultUser(cfg, q.Get("login_hint"))
+		}
+
+		code := s.randomToken()
+		s.oidc.storeCode(code, oidcGrant{
+			clientID:    client,
+			redirectURI: q.Get("redirect_uri"),
+			username:    user.Username,
+			scope:       q.Get("scope"),
+			nonce:       q.Get("nonce"),
+			challenge:   q.Get("code_challenge"),
+			method:      q.Get("code_challenge_method"),
+			authTime:    s.clock.Now(),
+			expires:     s.clock.Now().Add(oidcCodeTTL),
+		})
+		s.logger.Info("OIDC login", "client", client, "user", user.Username)
+
+		redirect(url.Values{"code": {code}})
+
+		return nil
+	}
+}
+
+func renderLoginPage(rw http.ResponseWriter, cfg *conf.OIDCConfig, client, message string) error {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if message != "" {
+		rw.WriteHeader(http.StatusUnauthorized)
+	}
+
+	return oidcLoginPage.Execute(rw, map[string]any{
+		"Client": client,
+		"Users":  cfg.Users,
+		"Error":  message,
+	})
+}
+
+// oidcClient checks the client and its redirect URI. Without configured
+// clients every client is welcome.
+func oidcClient(cfg *conf.OIDCConfig, clientID, redirectURI string) (string, error) {
+	if clientID == "" {
+		return "", errors.New("missing client_id")
+	}
+
+	if _, err := url.ParseRequestURI(redirectURI); err != nil {
+		return "", fmt.Errorf("invalid redirect_uri: %w", err)
+	}
+
+	if len(cfg.Clients) == 0 {
+		return clientID, nil
+	}
+
+	client := findOIDCClient(cfg, clientID)
+	if client == nil {
+		return "", fmt.Errorf("unknown client %q", clientID)
+	}
+
+	if len(client.RedirectURIs) > 0 && !slices.Contains(client.RedirectURIs, redirectURI) {
+		return "", fmt.Errorf("redirect_uri %q is not registered", redirectURI)
+	}
+
+	return clientID, nil
+}
+
+func findOIDCClient(cfg *conf.OIDCConfig, clientID string) *conf.OIDCClientConfig {
+	for i := range cfg.Clients {
+		if cfg.Clients[i].ID == clientID {
+			return &cfg.Clients[i]
+		}
+	}
+
+	return nil
+}
+
+func findOIDCUser(cfg *conf.OIDCConfig, username string) *conf.OIDCUserConfig {
+	for i := range cfg.Users {
+		if cfg.Users[i].Username == username {
+			return &cfg.Users[i]
+		}
+	}
+
+	return nil
+}
+
+// oidcLogin checks the password of a user. Users without a password can
+// log in with any.
+func oidcLogin(cfg *conf.OIDCConfig, username, password string) *conf.OIDCUserConfig {
+	user := findOIDCUser(cfg, username)
+	if user == nil {
+		return nil
+	}
+
+	if user.Password != "" && subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		return nil
+	}
+
+	return user
+}
+
+// oidcDefaultUser picks the user of the login hint, or the first one.
+func oidcDefaultUser(cfg *conf.OIDCConfig, hint string) *conf.OIDCUserConfig {
+	if user := findOIDCUser(cfg, hint); user != nil {
+		return user
+	}
+
+	return &cfg.Users[0]
+}
+
+func (s *Server) oidcToken(cfg *conf.OIDCConfig) responder {
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			writeOAuthError(rw, &oauthError{code: "invalid_request", description: err.Error()})
+			return nil
+		}
+
+		clientID, authErr := oidcClientAuth(cfg, r)
+		if authErr != nil {
+			writeOAuthError(rw, authErr)
+			return nil
+		}
+
+		var (
+			grant   oidcGrant
+			grantOK bool
+		)
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			grant, grantOK = s.oidc.takeCode(r.PostForm.Get("code"))
+			if !grantOK || s.clock.Now().After(grant.expires) ||
+				grant.clientID != clientID || grant.redirectURI != r.PostForm.Get("redirect_uri") {
+				writeOAuthError(rw, &oauthError{code: "invalid_grant", description: "invalid authorization code"})
+				return nil
+			}
+
+			if !verifyPKCE(grant, r.PostForm.Get("code_verifier")) {
+				writeOAuthError(rw, &oauthError{code: "invalid_grant", description: "code_verifier does not match"})
+				return nil
+			}
+		case "refresh_token":
+			grant, grantOK = s.oidc.takeRefreshToken(r.PostForm.Get("refresh_token"))
+			if !grantOK || grant.clientID != clientID {
+				writeOAuthError(rw, &oauthError{code: "invalid_grant", description: "invalid refresh token"})
+				return nil
+			}
+		case "client_credentials":
+			grant = oidcGrant{clientID: clientID, scope: r.PostForm.Get("scope")}
+		default:
+			writeOAuthError(rw, &oauthError{code: "unsupported_grant_type", description: "unsupported grant_type"})
+			return nil
+		}
+
+		resp, err := s.issueTokens(cfg, r, grant)
+		if err != nil {
+			return fmt.Errorf("issue tokens: %w", err)
+		}
+
+		rw.Header().Set("Cache-Control", "no-store")
+		writeJSON(rw, http.StatusOK, resp)
+
+		return nil
+	}
+}
+
+// oidcClientAuth authenticates the client with client_secret_basic or
+// client_secret_post. Public clients send only their id.
+func oidcClientAuth(cfg *conf.OIDCConfig, r *http.Request) (string, *oauthError) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	if clientID == "" {
+		return "", &oauthError{status: http.StatusUnauthorized, code: "invalid_client", description: "missing client_id"}
+	}
+
+	if len(cfg.Clients) == 0 {
+		return clientID, nil
+	}
+
+	client := findOIDCClient(cfg, clientID)
+	if client == nil || (client.Secret != "" && subtle.ConstantTimeCompare([]byte(client.Secret), []byte(secret)) != 1) {
+		return "", &oauthError{status: http.StatusUnauthorized, code: "invalid_client", description: "client authentication failed"}
+	}
+
+	return clientID, nil
+}
+
+func verifyPKCE(grant oidcGrant, verifier string) bool {
+	if grant.challenge == "" {
+		return true
+	}
+
+	switch grant.method {
+	case "", "plain":
+		return verifier == grant.challenge
+	case "S256":
+		sum := sha256.Sum256([]byte(verifier))
+		return base64.RawURLEncoding.EncodeToString(sum[:]) == grant.challenge
+	default:
+		return false
+	}
+}
+
+// issueTokens returns the token response for a grant. Grants without a
+// user come from client credentials and get no ID token.
+func (s *Server) issueTokens(cfg *conf.OIDCConfig, r *http.Request, grant oidcGrant) (map[string]any, error) {
+	now := s.clock.Now()
+	issuer := oidcIssuer(cfg, r)
+
+	claims := jwt.MapClaims{"sub": grant.clientID}
+	if user := findOIDCUser(cfg, grant.username); user != nil {
+		claims = oidcUserClaims(user)
+	}
+
+	access := jwt.MapClaims{
+		"iss":       issuer,
+		"aud":       grant.clientID,
+		"client_id": grant.clientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(cfg.TokenTTL).Unix(),
+	}
+	if grant.scope != "" {
+		access["scope"] = grant.scope
+	}
+	for k, v := range claims {
+		access[k] = v
+	}
+
+	accessToken, err := s.oidc.sign(access)
+	if err != nil {
+		return nil, fmt.Errorf("sign access token: %w", err)
+	}
+
+	resp := map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(cfg.TokenTTL.Seconds()),
+	}
+	if grant.scope != "" {
+		resp["scope"] = grant.scope
+	}
+
+	if grant.username == "" {
+		return resp, nil
+	}
+
+	if slices.Contains(strings.Fields(grant.scope), "openid") {
+		id := jwt.MapClaims{
+			"iss":       issuer,
+			"aud":       grant.clientID,
+			"iat":       now.Unix(),
+			"exp":       now.Add(cfg.TokenTTL).Unix(),
+			"auth_time": grant.authTime.Unix(),
+		}
+		if grant.nonce != "" {
+			id["nonce"] = grant.nonce
+		}
+		for k, v := range claims {
+			id[k] = v
+		}
+
+		idToken, err := s.oidc.sign(id)
+		if err != nil {
+			return nil, fmt.Errorf("sign id token: %w", err)
+		}
+		resp["id_token"] = idToken
+	}
+
+	refreshToken := s.randomToken()
+	s.oidc.storeRefreshToken(refreshToken, grant)
+	resp["refresh_token"] = refreshToken
+
+	return resp, nil
+}
+
+func oidcUserClaims(user *conf.OIDCUserConfig) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"sub":                user.Username,
+		"preferred_username": user.Username,
+	}
+	for k, v := range user.Claims {
+		claims[k] = v
+	}
+
+	return claims
+}
+
+func (s *Server) oidcUserInfo(cfg *conf.OIDCConfig) responder {
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeOAuthError(rw, &oauthError{status: http.StatusUnauthorized, code: "invalid_token", description: "missing bearer token"})
+			return nil
+		}
+
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+			return &s.oidc.key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithTimeFunc(s.clock.Now))
+		if err != nil {
+			rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeOAuthError(rw, &oauthError{status: http.StatusUnauthorized, code: "invalid_token", description: err.Error()})
+			return nil
+		}
+
+		sub, _ := claims["sub"].(string)
+		for i := range cfg.Users {
+			if user := oidcUserClaims(&cfg.Users[i]); user["sub"] == sub {
+				writeJSON(rw, http.StatusOK, user)
+				return nil
+			}
+		}
+
+		writeJSON(rw, http.StatusOK, map[string]any{"sub": sub})
+
+		return nil
+	}
+}
+
+func writeOAuthError(rw http.ResponseWriter, err *oauthError) {
+	status := err.status
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+
+	writeJSON(rw, status, map[string]string{
+		"error":             err.code,
+		"error_description": err.description,
+	})
+}
+
+func (s *Server) randomToken() string {
+	return fmt.Sprintf("%016x%016x", s.random.Uint64(), s.random.Uint64())
+}
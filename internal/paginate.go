@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/paginate"
+)
+
+// withPagination slices the JSON array the route responds with, according
+// to the query of the request. Error responses are left alone.
+func (s *Server) withPagination(route conf.RouteConfig, respond responder) responder {
+	cfg := route.Paginate
+	opts := paginate.Options{
+		Style:        cfg.Style,
+		DefaultLimit: cfg.DefaultLimit,
+		MaxLimit:     cfg.MaxLimit,
+		Params:       cfg.Params,
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		buf := newBufferedWriter(rw)
+		if err := respond(buf, r); err != nil {
+			return err
+		}
+
+		status, body := buf.status, buf.body.Bytes()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		if status >= http.StatusMultipleChoices {
+			rw.WriteHeader(status)
+			_, err := rw.Write(body)
+			return err
+		}
+
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return fmt.Errorf("paginate: body is not a JSON array: %w", err)
+		}
+
+		page, err := paginate.Paginate(opts, r.URL.Query(), len(items))
+		if err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]string{
+				"error":             "invalid_pagination",
+				"error_description": err.Error(),
+			})
+			return nil
+		}
+
+		rw.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+		if link := linkHeader(r, page); link != "" {
+			rw.Header().Set("Link", link)
+		}
+
+		data, err := json.Marshal(pageEnvelope(cfg.Envelope, page, items[page.Offset:page.End()]))
+		if err != nil {
+			return fmt.Errorf("encode page: %w", err)
+		}
+
+		rw.WriteHeader(status)
+		if _, err := rw.Write(data); err != nil {
+			return fmt.Errorf("write body: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// pageEnvelope wraps the items in an object with the fields the envelope
+// names, or returns them as they are without one.
+func pageEnvelope(envelope map[string]string, page paginate.Page, items []json.RawMessage) any {
+	if len(envelope) == 0 {
+		return items
+	}
+
+	values := map[string]any{
+		"items":       items,
+		"total":       page.Total,
+		"offset":      page.Offset,
+		"limit":       page.Limit,
+		"page":        page.Number(),
+		"size":        page.Limit,
+		"next_cursor": nilIfEmpty(page.NextCursor),
+		"prev_cursor": nilIfEmpty(page.PrevCursor),
+	}
+
+	out := make(map[string]any, len(envelope))
+	for role, name := range envelope {
+		out[name] = values[role]
+	}
+
+	return out
+}
+
+// linkHeader points to the neighbouring pages (RFC 8288).
+func linkHeader(r *http.Request, page paginate.Page) string {
+	var links []string
+	for _, link := range []struct {
+		rel   string
+		query url.Values
+	}{
+		{"first", page.First},
+		{"prev", page.Prev},
+		{"next", page.Next},
+		{"last", page.Last},
+	} {
+		if link.query == nil {
+			continue
+		}
+
+		u := *r.URL
+		u.RawQuery = link.query.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), link.rel))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+func nilIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
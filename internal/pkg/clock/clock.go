@@ -0,0 +1,65 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time used by responses. It follows the real time unless
+// it was frozen, and can be moved around in both modes.
+type Clock struct {
+	mutex  sync.RWMutex
+	offset time.Duration
+	frozen bool
+	at     time.Time
+}
+
+func New() *Clock {
+	return &Clock{}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.now()
+}
+
+func (c *Clock) now() time.Time {
+	if c.frozen {
+		return c.at
+	}
+
+	return time.Now().Add(c.offset).Round(0)
+}
+
+func (c *Clock) Frozen() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.frozen
+}
+
+// Set moves the clock to t. A frozen clock stays at t, a running one keeps
+// ticking from it. A zero t keeps the current time, so that the clock can
+// be frozen or let go without moving it.
+func (c *Clock) Set(t time.Time, frozen bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if t.IsZero() {
+		t = c.now()
+	}
+
+	c.frozen = frozen
+	c.at = t
+	c.offset = time.Until(t)
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.at = c.at.Add(d)
+	c.offset += d
+}
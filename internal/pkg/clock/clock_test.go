@@ -0,0 +1,48 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClock(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Follows the real time by default", func(t *testing.T) {
+		c := New()
+		require.False(t, c.Frozen())
+		require.WithinDuration(t, time.Now(), c.Now(), time.Second)
+	})
+
+	t.Run("A frozen clock stays put until advanced", func(t *testing.T) {
+		c := New()
+		c.Set(at, true)
+
+		time.Sleep(time.Millisecond)
+		require.True(t, c.Now().Equal(at))
+
+		c.Advance(time.Hour)
+		require.True(t, c.Now().Equal(at.Add(time.Hour)))
+	})
+
+	t.Run("A running clock ticks from the time it was set to", func(t *testing.T) {
+		c := New()
+		c.Set(at, false)
+		c.Advance(time.Hour)
+
+		require.WithinDuration(t, at.Add(time.Hour), c.Now(), time.Second)
+	})
+
+	t.Run("A zero time freezes the clock where it is", func(t *testing.T) {
+		c := New()
+		c.Set(time.Time{}, true)
+		require.WithinDuration(t, time.Now(), c.Now(), time.Second)
+
+		c.Advance(time.Hour)
+		c.Set(time.Time{}, false)
+		require.False(t, c.Frozen())
+		require.WithinDuration(t, time.Now().Add(time.Hour), c.Now(), time.Second)
+	})
+}
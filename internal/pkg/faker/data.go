@@ -0,0 +1,78 @@
+package faker
+
+var firstNames = []string{
+	"James", "Mary", "Robert", "Patricia", "John", "Jennifer", "Michael", "Linda",
+	"David", "Elizabeth", "William", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+	"Thomas", "Sarah", "Charles", "Karen", "Daniel", "Lisa", "Matthew", "Nancy",
+	"Anthony", "Betty", "Mark", "Sandra", "Andrei", "Olga", "Lucas", "Emma",
+	"Noah", "Olivia", "Liam", "Ava", "Mateo", "Sofia", "Hiro", "Yuki",
+}
+
+var lastNames = []string{
+	"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+	"Rodriguez", "Martinez", "Hernandez", "Lopez", "Gonzalez", "Wilson", "Anderson", "Thomas",
+	"Taylor", "Moore", "Jackson", "Martin", "Lee", "Perez", "Thompson", "White",
+	"Harris", "Clark", "Lewis", "Walker", "Kuzmin", "Novak", "Schmidt", "Rossi",
+	"Dubois", "Tanaka", "Kim", "Nguyen", "Silva", "Kowalski", "Jensen", "Murphy",
+}
+
+var emailDomains = []string{
+	"example.com", "example.org", "example.net", "mail.test", "inbox.test",
+}
+
+var streetNames = []string{
+	"Main", "Oak", "Pine", "Maple", "Cedar", "Elm", "Washington", "Lake",
+	"Hill", "Park", "Sunset", "River", "Church", "Mill", "Spring", "Highland",
+}
+
+var streetSuffixes = []string{
+	"Street", "Avenue", "Road", "Lane", "Boulevard", "Drive", "Court", "Way",
+}
+
+var cities = []string{
+	"Springfield", "Riverside", "Fairview", "Franklin", "Greenville", "Bristol", "Clinton", "Salem",
+	"Madison", "Georgetown", "Arlington", "Ashland", "Dover", "Oxford", "Jackson", "Burlington",
+}
+
+var countries = []string{
+	"United States", "Canada", "United Kingdom", "Germany", "France", "Spain", "Italy", "Netherlands",
+	"Sweden", "Poland", "Japan", "Brazil", "Australia", "India", "Mexico", "Norway",
+}
+
+var companyPrefixes = []string{
+	"Acme", "Globex", "Initech", "Umbrella", "Stark", "Wayne", "Hooli", "Vandelay",
+	"Soylent", "Cyberdyne", "Wonka", "Tyrell", "Aperture", "Oscorp", "Massive", "Blue Sun",
+}
+
+var companySuffixes = []string{
+	"Inc", "LLC", "Group", "Corp", "Industries", "Labs", "Systems", "Partners",
+}
+
+var loremWords = []string{
+	"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit",
+	"sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et",
+	"dolore", "magna", "aliqua", "enim", "ad", "minim", "veniam", "quis",
+	"nostrud", "exercitation", "ullamco", "laboris", "nisi", "aliquip", "ex", "ea",
+	"commodo", "consequat", "duis", "aute", "irure", "in", "reprehenderit", "voluptate",
+}
+
+var colorNames = []string{
+	"red", "green", "blue", "yellow", "orange", "purple", "pink", "brown",
+	"black", "white", "gray", "cyan", "magenta", "teal", "navy", "olive",
+}
+
+// ibanFormats maps a country code to the length of its BBAN, which is
+// filled with digits.
+var ibanFormats = []struct {
+	country string
+	length  int
+}{
+	{"DE", 18},
+	{"FR", 23},
+	{"ES", 20},
+	{"NL", 14},
+	{"BE", 12},
+	{"AT", 16},
+	{"CH", 17},
+	{"PL", 24},
+}
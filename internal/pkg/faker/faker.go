@@ -0,0 +1,199 @@
+package faker
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Generated dates fall into this range, so that they do not depend on the
+// current time and stay stable for a given seed.
+var (
+	minDate = time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxDate = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type Faker struct {
+	rnd *rand.Rand
+}
+
+func New(seed int64) *Faker {
+	return &Faker{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (f *Faker) Seed(seed int64) {
+	f.rnd.Seed(seed)
+}
+
+// SeedString seeds the faker with a string. Numeric strings are used as is,
+// everything else is hashed.
+func SeedString(s string) int64 {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	return int64(h.Sum64())
+}
+
+func (f *Faker) Int(min, max int) int {
+	if max <= min {
+		return min
+	}
+
+	return min + f.rnd.Intn(max-min+1)
+}
+
+func (f *Faker) FirstName() string {
+	return f.pick(firstNames)
+}
+
+func (f *Faker) LastName() string {
+	return f.pick(lastNames)
+}
+
+func (f *Faker) Name() string {
+	return f.FirstName() + " " + f.LastName()
+}
+
+func (f *Faker) Email() string {
+	user := strings.ToLower(f.FirstName() + "." + f.LastName())
+
+	return fmt.Sprintf("%s%d@%s", user, f.rnd.Intn(100), f.pick(emailDomains))
+}
+
+func (f *Faker) Phone() string {
+	return fmt.Sprintf("+1-%03d-%03d-%04d", f.Int(200, 999), f.Int(200, 999), f.rnd.Intn(10000))
+}
+
+func (f *Faker) Street() string {
+	return fmt.Sprintf("%d %s %s", f.Int(1, 9999), f.pick(streetNames), f.pick(streetSuffixes))
+}
+
+func (f *Faker) City() string {
+	return f.pick(cities)
+}
+
+func (f *Faker) Country() string {
+	return f.pick(countries)
+}
+
+func (f *Faker) Zip() string {
+	return fmt.Sprintf("%05d", f.rnd.Intn(100000))
+}
+
+func (f *Faker) Address() string {
+	return fmt.Sprintf("%s, %s %s, %s", f.Street(), f.City(), f.Zip(), f.Country())
+}
+
+func (f *Faker) Company() string {
+	return f.pick(companyPrefixes) + " " + f.pick(companySuffixes)
+}
+
+func (f *Faker) Word() string {
+	return f.pick(loremWords)
+}
+
+func (f *Faker) Words(n int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = f.Word()
+	}
+
+	return strings.Join(words, " ")
+}
+
+func (f *Faker) Sentence() string {
+	s := f.Words(f.Int(5, 12))
+
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+func (f *Faker) Paragraph() string {
+	sentences := make([]string, f.Int(3, 6))
+	for i := range sentences {
+		sentences[i] = f.Sentence()
+	}
+
+	return strings.Join(sentences, " ")
+}
+
+func (f *Faker) Date() time.Time {
+	span := maxDate.Sub(minDate)
+
+	return minDate.Add(time.Duration(f.rnd.Int63n(int64(span)))).Truncate(time.Second)
+}
+
+func (f *Faker) IPv4() string {
+	return fmt.Sprintf("%d.%d.%d.%d", f.Int(1, 254), f.rnd.Intn(256), f.rnd.Intn(256), f.Int(1, 254))
+}
+
+func (f *Faker) IPv6() string {
+	groups := make([]string, 8)
+	for i := range groups {
+		groups[i] = fmt.Sprintf("%x", f.rnd.Intn(0x10000))
+	}
+
+	return strings.Join(groups, ":")
+}
+
+func (f *Faker) Color() string {
+	return f.pick(colorNames)
+}
+
+func (f *Faker) HexColor() string {
+	return fmt.Sprintf("#%06x", f.rnd.Intn(0x1000000))
+}
+
+func (f *Faker) ImageURL(width, height int) string {
+	return fmt.Sprintf("https://picsum.photos/seed/%d/%d/%d", f.rnd.Intn(100000), width, height)
+}
+
+// IBAN returns an IBAN with a valid checksum.
+func (f *Faker) IBAN() string {
+	format := ibanFormats[f.rnd.Intn(len(ibanFormats))]
+
+	var bban strings.Builder
+	for i := 0; i < format.length; i++ {
+		bban.WriteByte(byte('0' + f.rnd.Intn(10)))
+	}
+
+	return format.country + ibanChecksum(format.country, bban.String()) + bban.String()
+}
+
+func (f *Faker) UUID() string {
+	b := make([]byte, 16)
+	_, _ = f.rnd.Read(b)
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (f *Faker) pick(list []string) string {
+	return list[f.rnd.Intn(len(list))]
+}
+
+// ibanChecksum computes the check digits as described in ISO 13616.
+func ibanChecksum(country, bban string) string {
+	var digits strings.Builder
+	for _, c := range bban + country + "00" {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(c-'A') + 10))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	mod := new(big.Int).Mod(n, big.NewInt(97)).Int64()
+
+	return fmt.Sprintf("%02d", 98-mod)
+}
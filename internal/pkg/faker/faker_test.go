@@ -0,0 +1,67 @@
+package faker
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaker(t *testing.T) {
+	t.Run("Same seed yields same data", func(t *testing.T) {
+		first, second := New(42), New(42)
+
+		for i := 0; i < 10; i++ {
+			require.Equal(t, first.Name(), second.Name())
+			require.Equal(t, first.Email(), second.Email())
+			require.Equal(t, first.Date(), second.Date())
+		}
+	})
+
+	t.Run("Reseeding restarts the sequence", func(t *testing.T) {
+		f := New(1)
+		name := f.Name()
+
+		f.Name()
+		f.Seed(1)
+
+		require.Equal(t, name, f.Name())
+	})
+
+	t.Run("Generated IBANs have valid checksums", func(t *testing.T) {
+		f := New(7)
+
+		for i := 0; i < 50; i++ {
+			iban := f.IBAN()
+			require.True(t, validIBAN(iban), "invalid IBAN %q", iban)
+		}
+	})
+
+	t.Run("Numeric string seeds are used as is", func(t *testing.T) {
+		require.Equal(t, int64(15), SeedString("15"))
+		require.Equal(t, SeedString("abc"), SeedString("abc"))
+		require.NotEqual(t, SeedString("abc"), SeedString("abd"))
+	})
+}
+
+func validIBAN(iban string) bool {
+	rearranged := iban[4:] + iban[:4]
+
+	var digits strings.Builder
+	for _, c := range rearranged {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(c-'A') + 10))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
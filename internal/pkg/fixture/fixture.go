@@ -0,0 +1,100 @@
+// Package fixture loads data sets from JSON, YAML and CSV files.
+package fixture
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnknownFormat = errors.New("unknown fixture format")
+
+// Supported reports whether the extension of path is one Load reads.
+func Supported(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yml", ".yaml", ".csv":
+		return true
+	default:
+		return false
+	}
+}
+
+// Load reads a fixture file, picking the format by its extension. CSV
+// files become a list of objects keyed by the header row.
+func Load(path string) (any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		var data any
+		if err := json.NewDecoder(f).Decode(&data); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+		return data, nil
+	case ".yml", ".yaml":
+		var data any
+		if err := yaml.NewDecoder(f).Decode(&data); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("decode yaml: %w", err)
+		}
+		return data, nil
+	case ".csv":
+		return readCSV(f)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, ext)
+	}
+}
+
+func readCSV(r io.Reader) ([]any, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("decode csv: %w", err)
+	}
+
+	rows := make([]any, 0, max(len(records)-1, 0))
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = csvValue(record[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// csvValue turns numbers and booleans into their types. Values that would
+// not survive the round trip, like "007", stay strings.
+func csvValue(s string) any {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(n, 10) == s {
+		return n
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == s {
+		return f
+	}
+
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return b
+	}
+
+	return s
+}
@@ -0,0 +1,38 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/kaato137/quickrest/internal/pkg/testfile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("JSON", func(t *testing.T) {
+		data, err := Load(testfile.Write(t, dir, "users.json", `[{"id": 1, "name": "Ann"}]`))
+		require.NoError(t, err)
+		require.Equal(t, []any{map[string]any{"id": 1.0, "name": "Ann"}}, data)
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		data, err := Load(testfile.Write(t, dir, "config.yaml", "plans:\n  - free\n  - pro\n"))
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"plans": []any{"free", "pro"}}, data)
+	})
+
+	t.Run("CSV rows are typed", func(t *testing.T) {
+		data, err := Load(testfile.Write(t, dir, "products.csv", "id,name,price,zip,active\n1,Pen,1.5,007,true\n2,\"Ink, blue\",3,10115,false\n"))
+		require.NoError(t, err)
+		require.Equal(t, []any{
+			map[string]any{"id": int64(1), "name": "Pen", "price": 1.5, "zip": "007", "active": true},
+			map[string]any{"id": int64(2), "name": "Ink, blue", "price": int64(3), "zip": int64(10115), "active": false},
+		}, data)
+	})
+
+	t.Run("Unknown extensions are rejected", func(t *testing.T) {
+		_, err := Load(testfile.Write(t, dir, "data.txt", "x"))
+		require.ErrorIs(t, err, ErrUnknownFormat)
+	})
+}
@@ -0,0 +1,386 @@
+package gqlmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const defaultListLength = 2
+
+// ResolveFunc overrides the generated value of a field. It reports ok=false
+// when it has no opinion about the field. A nil value with ok=true answers
+// null.
+type ResolveFunc func(field FieldContext) (value any, ok bool, err error)
+
+type FieldContext struct {
+	TypeName  string
+	FieldName string
+	Args      map[string]any
+	Parent    any
+}
+
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type Response struct {
+	Data   any           `json:"data,omitempty"`
+	Errors gqlerror.List `json:"errors,omitempty"`
+}
+
+type executor struct {
+	schema     *ast.Schema
+	listLength int
+	seed       int64
+	resolveFn  ResolveFunc
+}
+
+func LoadSchema(name, sdl string) (*ast.Schema, error) {
+	return gqlparser.LoadSchema(&ast.Source{Name: name, Input: sdl})
+}
+
+func NewExecutor(schema *ast.Schema) *executor {
+	return &executor{schema: schema, listLength: defaultListLength}
+}
+
+func (e *executor) WithListLength(n int) *executor {
+	e.listLength = n
+
+	return e
+}
+
+// WithSeed changes the generated data. The same seed and query always
+// produce the same data.
+func (e *executor) WithSeed(seed int64) *executor {
+	e.seed = seed
+
+	return e
+}
+
+func (e *executor) WithResolver(fn ResolveFunc) *executor {
+	e.resolveFn = fn
+
+	return e
+}
+
+// Execute validates req against the schema and answers it with generated
+// data. Generated values are derived from the field path, so the same query
+// always yields the same response.
+func (e *executor) Execute(req Request) Response {
+	doc, errs := gqlparser.LoadQuery(e.schema, req.Query)
+	if len(errs) > 0 {
+		return Response{Errors: errs}
+	}
+
+	op := doc.Operations.ForName(req.OperationName)
+	if op == nil {
+		return Response{Errors: gqlerror.List{gqlerror.Errorf("operation %q not found", req.OperationName)}}
+	}
+
+	root := e.rootType(op.Operation)
+	if root == nil {
+		return Response{Errors: gqlerror.List{gqlerror.Errorf("schema does not support %s", op.Operation)}}
+	}
+
+	run := &execution{executor: e, doc: doc, vars: req.Variables}
+
+	data, ok := run.selectionSet(root, op.SelectionSet, nil, nil)
+	if !ok {
+		// A null reached the root through non-null fields.
+		return Response{Data: json.RawMessage("null"), Errors: run.errs}
+	}
+
+	return Response{Data: data, Errors: run.errs}
+}
+
+func (e *executor) rootType(op ast.Operation) *ast.Definition {
+	switch op {
+	case ast.Mutation:
+		return e.schema.Mutation
+	case ast.Subscription:
+		return e.schema.Subscription
+	default:
+		return e.schema.Query
+	}
+}
+
+type execution struct {
+	*executor
+
+	doc  *ast.QueryDocument
+	vars map[string]any
+	errs gqlerror.List
+}
+
+// selectionSet completes the fields of an object. It reports ok=false when
+// a non-null field is null, which makes the whole object null.
+func (x *execution) selectionSet(def *ast.Definition, set ast.SelectionSet, parent any, path ast.Path) (*object, bool) {
+	concrete := x.concreteType(def, parent)
+	result := &object{}
+
+	for _, field := range x.collectFields(concrete, set) {
+		fieldPath := append(append(ast.Path{}, path...), ast.PathName(field.Alias))
+
+		if field.Name == "__typename" {
+			result.set(field.Alias, concrete.Name)
+			continue
+		}
+
+		if strings.HasPrefix(field.Name, "__") {
+			result.set(field.Alias, nil)
+			continue
+		}
+
+		value, given, err := x.resolveField(concrete, field, parent)
+		if err != nil {
+			x.errs = append(x.errs, gqlerror.WrapPath(fieldPath, err))
+			if field.Definition.Type.NonNull {
+				return nil, false
+			}
+			result.set(field.Alias, nil)
+			continue
+		}
+
+		completed, ok := x.complete(concrete, field, field.Definition.Type, value, given, fieldPath)
+		if !ok {
+			return nil, false
+		}
+		result.set(field.Alias, completed)
+	}
+
+	return result, true
+}
+
+// resolveField returns the value of a field and whether it was given, by
+// the resolver or the parent value, rather than left to be generated.
+func (x *execution) resolveField(def *ast.Definition, field *ast.Field, parent any) (any, bool, error) {
+	if x.resolveFn != nil {
+		value, ok, err := x.resolveFn(FieldContext{
+			TypeName:  def.Name,
+			FieldName: field.Name,
+			Args:      field.ArgumentMap(x.vars),
+			Parent:    parent,
+		})
+		if err != nil || ok {
+			return value, ok, err
+		}
+	}
+
+	if m, ok := parent.(map[string]any); ok {
+		value, given := m[field.Name]
+		return value, given, nil
+	}
+
+	return nil, false, nil
+}
+
+// complete turns value into the response value of typ. Values that were not
+// given are generated, while a given nil stays null. It reports ok=false
+// when the value is null although typ is non-null, so that the parent
+// becomes null in turn.
+func (x *execution) complete(parent *ast.Definition, field *ast.Field, typ *ast.Type, value any, given bool, path ast.Path) (any, bool) {
+	if given && value == nil {
+		if typ.NonNull {
+			x.errs = append(x.errs, gqlerror.ErrorPathf(path, "Cannot return null for non-nullable field %s.%s.", parent.Name, field.Name))
+			return nil, false
+		}
+		return nil, true
+	}
+
+	var (
+		result any
+		ok     = true
+	)
+
+	if typ.Elem != nil {
+		items, isList := value.([]any)
+		if !isList {
+			items = make([]any, x.listLength)
+		}
+
+		list := make([]any, len(items))
+		for i := range items {
+			itemPath := append(append(ast.Path{}, path...), ast.PathIndex(i))
+			if list[i], ok = x.complete(parent, field, typ.Elem, items[i], isList, itemPath); !ok {
+				break
+			}
+		}
+		result = list
+	} else if def := x.schema.Types[typ.NamedType]; def.IsCompositeType() {
+		result, ok = x.selectionSet(def, field.SelectionSet, value, path)
+	} else if value != nil {
+		result = value
+	} else {
+		result = generateLeaf(def, field.Name, pathSeed(path)^x.seed)
+	}
+
+	if !ok {
+		// A non-null value inside is null, so this one is null too.
+		return nil, !typ.NonNull
+	}
+
+	return result, true
+}
+
+// concreteType picks the object type to use for an interface or union.
+// A __typename in the parent value wins, otherwise the first possible type
+// is used.
+func (x *execution) concreteType(def *ast.Definition, parent any) *ast.Definition {
+	if !def.IsAbstractType() {
+		return def
+	}
+
+	if m, ok := parent.(map[string]any); ok {
+		if name, ok := m["__typename"].(string); ok {
+			if t, ok := x.schema.Types[name]; ok {
+				return t
+			}
+		}
+	}
+
+	possible := x.schema.GetPossibleTypes(def)
+	if len(possible) == 0 {
+		return def
+	}
+
+	return possible[0]
+}
+
+func (x *execution) collectFields(def *ast.Definition, set ast.SelectionSet) []*ast.Field {
+	var fields []*ast.Field
+	seen := make(map[string]bool)
+
+	var walk func(set ast.SelectionSet)
+	walk = func(set ast.SelectionSet) {
+		for _, sel := range set {
+			switch sel := sel.(type) {
+			case *ast.Field:
+				if !seen[sel.Alias] {
+					seen[sel.Alias] = true
+					fields = append(fields, sel)
+				}
+			case *ast.InlineFragment:
+				if x.fragmentApplies(def, sel.TypeCondition) {
+					walk(sel.SelectionSet)
+				}
+			case *ast.FragmentSpread:
+				frag := x.doc.Fragments.ForName(sel.Name)
+				if frag != nil && x.fragmentApplies(def, frag.TypeCondition) {
+					walk(frag.SelectionSet)
+				}
+			}
+		}
+	}
+	walk(set)
+
+	return fields
+}
+
+func (x *execution) fragmentApplies(def *ast.Definition, typeCondition string) bool {
+	if typeCondition == "" || typeCondition == def.Name {
+		return true
+	}
+
+	for _, iface := range x.schema.GetImplements(def) {
+		if iface.Name == typeCondition {
+			return true
+		}
+	}
+
+	return false
+}
+
+func generateLeaf(def *ast.Definition, fieldName string, seed int64) any {
+	rnd := rand.New(rand.NewSource(seed))
+
+	if def.Kind == ast.Enum {
+		if len(def.EnumValues) == 0 {
+			return nil
+		}
+		return def.EnumValues[rnd.Intn(len(def.EnumValues))].Name
+	}
+
+	switch def.Name {
+	case "Int":
+		return rnd.Intn(1000)
+	case "Float":
+		return float64(rnd.Intn(100000)) / 100
+	case "Boolean":
+		return rnd.Intn(2) == 1
+	case "ID":
+		return fmt.Sprint(rnd.Intn(10000) + 1)
+	case "String":
+		return fmt.Sprintf("%s %d", fieldName, rnd.Intn(1000))
+	}
+
+	name := strings.ToLower(def.Name)
+	if strings.Contains(name, "date") || strings.Contains(name, "time") {
+		base := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return base.Add(time.Duration(rnd.Intn(365*24)) * time.Hour).Format(time.RFC3339)
+	}
+
+	return fmt.Sprintf("%s %d", fieldName, rnd.Intn(1000))
+}
+
+func pathSeed(path ast.Path) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(path.String()))
+
+	return int64(h.Sum64())
+}
+
+// object keeps fields in selection order, as GraphQL responses should.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *object) set(key string, value any) {
+	if o.values == nil {
+		o.values = make(map[string]any)
+	}
+
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+
+	o.values[key] = value
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+
+	b.WriteString("{")
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(key)
+		b.WriteString(":")
+		b.Write(val)
+	}
+	b.WriteString("}")
+
+	return []byte(b.String()), nil
+}
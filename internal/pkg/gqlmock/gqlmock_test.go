@@ -0,0 +1,172 @@
+package gqlmock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `
+type Query {
+	user(id: ID!): User
+	users: [User!]!
+	node: Node
+}
+
+interface Node {
+	id: ID!
+}
+
+type User implements Node {
+	id: ID!
+	name: String!
+	age: Int
+	role: Role!
+}
+
+enum Role {
+	ADMIN
+	GUEST
+}
+`
+
+func TestExecute(t *testing.T) {
+	schema, err := LoadSchema("schema.graphql", testSchema)
+	require.NoError(t, err)
+
+	t.Run("Should generate type-correct data", func(t *testing.T) {
+		resp := NewExecutor(schema).Execute(Request{
+			Query: `{ users { id name age role } }`,
+		})
+		require.Empty(t, resp.Errors)
+
+		var data struct {
+			Users []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+				Age  int    `json:"age"`
+				Role string `json:"role"`
+			} `json:"users"`
+		}
+		decode(t, resp, &data)
+
+		require.Len(t, data.Users, defaultListLength)
+		for _, u := range data.Users {
+			require.NotEmpty(t, u.ID)
+			require.NotEmpty(t, u.Name)
+			require.Contains(t, []string{"ADMIN", "GUEST"}, u.Role)
+		}
+	})
+
+	t.Run("Same query yields same data", func(t *testing.T) {
+		query := Request{Query: `{ user(id: "1") { id name } }`}
+
+		first := NewExecutor(schema).Execute(query)
+		second := NewExecutor(schema).Execute(query)
+
+		require.Equal(t, marshal(t, first), marshal(t, second))
+	})
+
+	t.Run("Resolvers override generated values", func(t *testing.T) {
+		resp := NewExecutor(schema).
+			WithResolver(func(field FieldContext) (any, bool, error) {
+				if field.TypeName == "Query" && field.FieldName == "user" {
+					return map[string]any{"name": "Bob " + field.Args["id"].(string)}, true, nil
+				}
+				return nil, false, nil
+			}).
+			Execute(Request{
+				Query:     `query Get($id: ID!) { user(id: $id) { name } }`,
+				Variables: map[string]any{"id": "7"},
+			})
+		require.Empty(t, resp.Errors)
+
+		require.JSONEq(t, `{"data":{"user":{"name":"Bob 7"}}}`, marshal(t, resp))
+	})
+
+	t.Run("Resolvers can return null", func(t *testing.T) {
+		resp := NewExecutor(schema).
+			WithResolver(func(field FieldContext) (any, bool, error) {
+				switch field.TypeName + "." + field.FieldName {
+				case "Query.user":
+					return nil, true, nil
+				case "Query.node":
+					return map[string]any{"__typename": "User", "id": "1", "age": nil}, true, nil
+				}
+				return nil, false, nil
+			}).
+			Execute(Request{Query: `{ user(id: "1") { name } node { ... on User { id age } } }`})
+		require.Empty(t, resp.Errors)
+
+		require.JSONEq(t, `{"data":{"user":null,"node":{"id":"1","age":null}}}`, marshal(t, resp))
+	})
+
+	t.Run("Null non-null fields make their parent null", func(t *testing.T) {
+		resp := NewExecutor(schema).
+			WithResolver(func(field FieldContext) (any, bool, error) {
+				if field.TypeName == "Query" && field.FieldName == "user" {
+					return map[string]any{"id": "1", "name": nil}, true, nil
+				}
+				return nil, false, nil
+			}).
+			Execute(Request{Query: `{ user(id: "1") { id name } }`})
+
+		require.JSONEq(t, `{"user": null}`, marshal(t, resp.Data))
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, "Cannot return null for non-nullable field User.name.", resp.Errors[0].Message)
+		require.Equal(t, "user.name", resp.Errors[0].Path.String())
+	})
+
+	t.Run("Nulls propagate up to the root", func(t *testing.T) {
+		resp := NewExecutor(schema).
+			WithResolver(func(field FieldContext) (any, bool, error) {
+				if field.TypeName == "Query" && field.FieldName == "users" {
+					return []any{map[string]any{"id": "1"}, nil}, true, nil
+				}
+				return nil, false, nil
+			}).
+			Execute(Request{Query: `{ users { id } }`})
+
+		require.JSONEq(t, `{"data":null,"errors":[{"message":"Cannot return null for non-nullable field Query.users.","path":["users",1]}]}`, marshal(t, resp))
+	})
+
+	t.Run("Fragments on abstract types are resolved", func(t *testing.T) {
+		resp := NewExecutor(schema).Execute(Request{
+			Query: `{ node { __typename ... on User { role } } }`,
+		})
+		require.Empty(t, resp.Errors)
+
+		var data struct {
+			Node map[string]any `json:"node"`
+		}
+		decode(t, resp, &data)
+
+		require.Equal(t, "User", data.Node["__typename"])
+		require.Contains(t, data.Node, "role")
+	})
+
+	t.Run("Invalid queries are reported as errors", func(t *testing.T) {
+		resp := NewExecutor(schema).Execute(Request{Query: `{ unknown }`})
+
+		require.NotEmpty(t, resp.Errors)
+		require.Nil(t, resp.Data)
+	})
+}
+
+func marshal(t *testing.T, resp any) string {
+	t.Helper()
+
+	b, err := json.Marshal(resp)
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func decode(t *testing.T, resp Response, v any) {
+	t.Helper()
+
+	b, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, v))
+}
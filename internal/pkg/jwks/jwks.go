@@ -0,0 +1,142 @@
+// Package jwks reads JSON Web Key Sets as described in RFC 7517.
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrKeyNotFound      = errors.New("key not found")
+	ErrUnsupportedKey   = errors.New("unsupported key type")
+	ErrUnsupportedCurve = errors.New("unsupported curve")
+	ErrAmbiguousKey     = errors.New("token has no kid and the set has several keys")
+)
+
+type Set struct {
+	keys map[string]any
+	// order keeps the keys in the order of the document for tokens
+	// without a kid.
+	order []string
+}
+
+type jsonKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+
+	N string `json:"n"`
+	E string `json:"e"`
+
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	K string `json:"k"`
+}
+
+// Parse reads a key set. Keys meant for encryption are skipped.
+func Parse(data []byte) (*Set, error) {
+	var doc struct {
+		Keys []jsonKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode key set: %w", err)
+	}
+
+	set := &Set{keys: make(map[string]any)}
+	for i, k := range doc.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+
+		set.keys[k.Kid] = key
+		set.order = append(set.order, k.Kid)
+	}
+
+	return set, nil
+}
+
+// Key returns the key with the given id. An empty id is accepted when the
+// set holds a single key.
+func (s *Set) Key(kid string) (any, error) {
+	if kid == "" && len(s.order) > 1 {
+		return nil, ErrAmbiguousKey
+	}
+
+	if kid == "" && len(s.order) == 1 {
+		kid = s.order[0]
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+
+	return key, nil
+}
+
+func (k jsonKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n: %w", err)
+		}
+
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e: %w", err)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedCurve, k.Crv)
+		}
+
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode y: %w", err)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKey, k.Kty)
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,62 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	doc := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"}
+	]}`, encode(rsaKey.N), encode(big.NewInt(int64(rsaKey.E))), encode(ecKey.X), encode(ecKey.Y))
+
+	set, err := Parse([]byte(doc))
+	require.NoError(t, err)
+
+	t.Run("Finds RSA keys by id", func(t *testing.T) {
+		key, err := set.Key("rsa")
+		require.NoError(t, err)
+		require.True(t, rsaKey.PublicKey.Equal(key))
+	})
+
+	t.Run("Finds EC keys by id", func(t *testing.T) {
+		key, err := set.Key("ec")
+		require.NoError(t, err)
+		require.True(t, ecKey.PublicKey.Equal(key))
+	})
+
+	t.Run("Skips encryption keys", func(t *testing.T) {
+		_, err := set.Key("enc")
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("Needs a kid when there are several keys", func(t *testing.T) {
+		_, err := set.Key("")
+		require.ErrorIs(t, err, ErrAmbiguousKey)
+	})
+
+	t.Run("Rejects unknown key types", func(t *testing.T) {
+		_, err := Parse([]byte(`{"keys": [{"kty": "OKP"}]}`))
+		require.ErrorIs(t, err, ErrUnsupportedKey)
+	})
+}
+
+func encode(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
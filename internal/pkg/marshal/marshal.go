@@ -0,0 +1,105 @@
+// Package marshal encodes generic values, as decoded from YAML, to the
+// formats a structured route body can be served in.
+package marshal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatYAML    = "yaml"
+	FormatXML     = "xml"
+	FormatMsgPack = "msgpack"
+)
+
+var (
+	ErrUnknownFormat   = errors.New("unknown format")
+	ErrUnsupportedType = errors.New("unsupported type")
+)
+
+// ContentTypes are the default content types of the formats.
+var ContentTypes = map[string]string{
+	FormatJSON:    "application/json",
+	FormatYAML:    "application/yaml",
+	FormatXML:     "application/xml",
+	FormatMsgPack: "application/msgpack",
+}
+
+func Marshal(format string, v any) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.Marshal(normalize(v))
+	case FormatYAML:
+		return yaml.Marshal(v)
+	case FormatXML:
+		return XML(v)
+	case FormatMsgPack:
+		return MsgPack(v)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// normalize turns the map[any]any YAML produces for non-string keys into
+// map[string]any, which every format can encode.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = normalize(item)
+		}
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[fmt.Sprint(k)] = normalize(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// Map applies fn to every string in v, keys excluded, and returns the
+// result. v is left untouched.
+func Map(v any, fn func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = Map(item, fn)
+		}
+		return out
+	case map[any]any:
+		out := make(map[any]any, len(v))
+		for k, item := range v {
+			out[k] = Map(item, fn)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = Map(item, fn)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,83 @@
+package marshal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func decode(t *testing.T, text string) any {
+	t.Helper()
+
+	var v any
+	require.NoError(t, yaml.Unmarshal([]byte(text), &v))
+
+	return v
+}
+
+func TestMarshal(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		v := decode(t, "user: {id: 1, tags: [a, b], active: true, 2: two}")
+
+		data, err := Marshal(FormatJSON, v)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"user": {"id": 1, "tags": ["a", "b"], "active": true, "2": "two"}}`, string(data))
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		v := decode(t, `
+user:
+  "@id": 1
+  name: Ann & Bob
+  role: [admin, dev]
+  note: {"@lang": en, "#text": hi}
+`)
+
+		data, err := Marshal(FormatXML, v)
+		require.NoError(t, err)
+		require.Equal(t,
+			`<user id="1"><name>Ann &amp; Bob</name><note lang="en">hi</note><role>admin</role><role>dev</role></user>`,
+			string(data))
+	})
+
+	t.Run("XML needs a single root", func(t *testing.T) {
+		_, err := Marshal(FormatXML, decode(t, "a: 1\nb: 2"))
+		require.ErrorIs(t, err, ErrNoRootElement)
+	})
+
+	t.Run("MsgPack", func(t *testing.T) {
+		v := decode(t, `{a: 1, b: [-1, -200, true, null], c: 1.5, d: "`+strings.Repeat("x", 40)+`"}`)
+
+		data, err := Marshal(FormatMsgPack, v)
+		require.NoError(t, err)
+
+		expected := []byte{
+			0x84,
+			0xa1, 'a', 0x01,
+			0xa1, 'b', 0x94, 0xff, 0xd1, 0xff, 0x38, 0xc3, 0xc0,
+			0xa1, 'c', 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0,
+			0xa1, 'd', 0xd9, 40,
+		}
+		expected = append(expected, strings.Repeat("x", 40)...)
+		require.Equal(t, expected, data)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		_, err := Marshal("toml", nil)
+		require.ErrorIs(t, err, ErrUnknownFormat)
+	})
+}
+
+func TestMap(t *testing.T) {
+	v := decode(t, "id: '{id}'\nitems: [{name: '{id}'}, 3]")
+
+	mapped := Map(v, func(s string) string { return strings.ReplaceAll(s, "{id}", "7") })
+
+	require.Equal(t, map[string]any{
+		"id":    "7",
+		"items": []any{map[string]any{"name": "7"}, 3},
+	}, mapped)
+	require.Equal(t, "{id}", v.(map[string]any)["id"])
+}
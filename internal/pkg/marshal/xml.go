@@ -0,0 +1,101 @@
+package marshal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	attrPrefix = "@"
+	textKey    = "#text"
+)
+
+var ErrNoRootElement = errors.New("xml body must be a map with a single root element")
+
+// XML encodes a map with a single key, the root element. Keys starting
+// with "@" become attributes, "#text" becomes the text of its element and
+// lists repeat their element. Other keys are written in sorted order.
+func XML(v any) ([]byte, error) {
+	root, ok := normalize(v).(map[string]any)
+	if !ok || len(root) != 1 {
+		return nil, ErrNoRootElement
+	}
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+
+	for name, value := range root {
+		if err := encodeElement(enc, name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeElement(enc *xml.Encoder, name string, v any) error {
+	if list, ok := v.([]any); ok {
+		for _, item := range list {
+			if err := encodeElement(enc, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	fields, isMap := v.(map[string]any)
+	if !isMap {
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if v != nil {
+			if err := enc.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if attr, ok := strings.CutPrefix(k, attrPrefix); ok {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attr}, Value: fmt.Sprint(fields[k])})
+		}
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if text, ok := fields[textKey]; ok && text != nil {
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(text))); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range keys {
+		if k == textKey || strings.HasPrefix(k, attrPrefix) {
+			continue
+		}
+		if err := encodeElement(enc, k, fields[k]); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
@@ -0,0 +1,144 @@
+// Package negotiate picks a response media type from an Accept header.
+package negotiate
+
+import (
+	"strconv"
+	"strings"
+)
+
+type mediaRange struct {
+	typ     string
+	subtype string
+	quality float64
+}
+
+// Select returns the offer the Accept header prefers. Ties go to the
+// earlier offer, and an empty header accepts the first one. ok is false
+// when no offer is acceptable.
+func Select(accept string, offers []string) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	ranges := parse(accept)
+
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		if q := quality(ranges, offer); q > bestQuality {
+			best, bestQuality = offer, q
+		}
+	}
+
+	return best, bestQuality > 0
+}
+
+func parse(accept string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok {
+			continue
+		}
+
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, quality: parseQuality(params[1:])})
+	}
+
+	return ranges
+}
+
+// parseQuality reads the q parameter. Invalid values make the entry
+// unacceptable.
+func parseQuality(params []string) float64 {
+	for _, param := range params {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(key, "q") {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(value, 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0
+		}
+		return q
+	}
+
+	return 1
+}
+
+// quality is the q-value of the most specific range matching the offer.
+func quality(ranges []mediaRange, offer string) float64 {
+	typ, subtype, _ := strings.Cut(strings.ToLower(offer), "/")
+	if i := strings.IndexByte(subtype, ';'); i >= 0 {
+		subtype = strings.TrimSpace(subtype[:i])
+	}
+
+	q, specificity := 0.0, 0
+	for _, mr := range ranges {
+		s := 0
+		switch {
+		case mr.typ == typ && mr.subtype == subtype:
+			s = 3
+		case mr.typ == typ && mr.subtype == "*":
+			s = 2
+		case mr.typ == "*" && mr.subtype == "*":
+			s = 1
+		}
+
+		if s > specificity {
+			q, specificity = mr.quality, s
+		}
+	}
+
+	return q
+}
+
+// Encoding returns the content coding the Accept-Encoding header prefers
+// among offers, which are tried in order on ties. An empty result means
+// identity. ok is false when not even identity is acceptable.
+func Encoding(acceptEncoding string, offers []string) (string, bool) {
+	if strings.TrimSpace(acceptEncoding) == "" {
+		return "", true
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding == "" {
+			continue
+		}
+
+		qualities[coding] = parseQuality(params[1:])
+	}
+
+	lookup := func(coding string) (float64, bool) {
+		if q, ok := qualities[coding]; ok {
+			return q, true
+		}
+		q, ok := qualities["*"]
+		return q, ok
+	}
+
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		if q, _ := lookup(offer); q > bestQuality {
+			best, bestQuality = offer, q
+		}
+	}
+	if best != "" {
+		return best, true
+	}
+
+	// Identity is acceptable unless excluded explicitly.
+	q, listed := lookup("identity")
+
+	return "", !listed || q > 0
+}
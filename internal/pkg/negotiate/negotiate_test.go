@@ -0,0 +1,65 @@
+package negotiate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/csv"}
+
+	tests := []struct {
+		name     string
+		accept   string
+		expected string
+		ok       bool
+	}{
+		{name: "Empty header takes the first offer", accept: "", expected: "application/json", ok: true},
+		{name: "Exact match", accept: "text/csv", expected: "text/csv", ok: true},
+		{name: "Highest quality wins", accept: "application/json;q=0.5, application/xml", expected: "application/xml", ok: true},
+		{name: "Subtype wildcard", accept: "text/*", expected: "text/csv", ok: true},
+		{name: "Any type keeps the offer order", accept: "*/*", expected: "application/json", ok: true},
+		{name: "Specific ranges override wildcards", accept: "*/*;q=0.8, application/json;q=0", expected: "application/xml", ok: true},
+		{name: "Matching is case insensitive", accept: "Application/XML", expected: "application/xml", ok: true},
+		{name: "Nothing acceptable", accept: "image/png", ok: false},
+		{name: "Zero quality is not acceptable", accept: "text/csv;q=0", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Select(tt.accept, offers)
+			require.Equal(t, tt.ok, ok)
+			if tt.ok {
+				require.Equal(t, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	offers := []string{"br", "gzip", "deflate"}
+
+	tests := []struct {
+		name     string
+		accept   string
+		expected string
+		ok       bool
+	}{
+		{name: "Empty header means identity", accept: "", expected: "", ok: true},
+		{name: "Offer order breaks ties", accept: "gzip, deflate, br", expected: "br", ok: true},
+		{name: "Highest quality wins", accept: "br;q=0.5, gzip", expected: "gzip", ok: true},
+		{name: "Wildcard", accept: "*", expected: "br", ok: true},
+		{name: "Excluded codings are skipped", accept: "*, br;q=0", expected: "gzip", ok: true},
+		{name: "Unknown codings fall back to identity", accept: "zstd", expected: "", ok: true},
+		{name: "Identity may be excluded", accept: "zstd, identity;q=0", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Encoding(tt.accept, offers)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
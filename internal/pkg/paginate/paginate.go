@@ -0,0 +1,195 @@
+// Package paginate works out which part of a list a request asks for, by
+// offset and limit, page and size, or an opaque cursor.
+package paginate
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	StyleOffset = "offset"
+	StylePage   = "page"
+	StyleCursor = "cursor"
+)
+
+// Parameter roles, which Options.Params maps to query parameter names.
+const (
+	ParamOffset = "offset"
+	ParamLimit  = "limit"
+	ParamPage   = "page"
+	ParamSize   = "size"
+	ParamCursor = "cursor"
+)
+
+const cursorPrefix = "offset:"
+
+var (
+	ErrInvalidParam  = errors.New("invalid pagination parameter")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+type Options struct {
+	Style        string
+	DefaultLimit int
+	MaxLimit     int
+	// Params renames query parameters. Roles without an entry keep their
+	// own name.
+	Params map[string]string
+}
+
+// Page is the part of a list of Total items starting at Offset. The
+// links hold the query parameters of the neighbouring pages and are nil
+// where there is no such page.
+type Page struct {
+	Offset int
+	Limit  int
+	Total  int
+
+	First, Prev, Next, Last url.Values
+
+	PrevCursor string
+	NextCursor string
+}
+
+// Number is the 1-based page number.
+func (p Page) Number() int {
+	return p.Offset/p.Limit + 1
+}
+
+// End is the offset after the last item of the page. It never overflows,
+// even for huge limits.
+func (p Page) End() int {
+	if p.Offset >= p.Total {
+		return p.Offset
+	}
+
+	return p.Offset + min(p.Limit, p.Total-p.Offset)
+}
+
+func Paginate(opts Options, query url.Values, total int) (Page, error) {
+	param := func(role string) string {
+		if name := opts.Params[role]; name != "" {
+			return name
+		}
+		return role
+	}
+
+	p := Page{Total: total}
+
+	limitParam := param(ParamLimit)
+	if opts.Style == StylePage {
+		limitParam = param(ParamSize)
+	}
+
+	var err error
+	if p.Limit, err = intParam(query, limitParam, opts.DefaultLimit, 1); err != nil {
+		return Page{}, err
+	}
+	if opts.MaxLimit > 0 {
+		p.Limit = min(p.Limit, opts.MaxLimit)
+	}
+
+	switch opts.Style {
+	case StylePage:
+		number, err := intParam(query, param(ParamPage), 1, 1)
+		if err != nil {
+			return Page{}, err
+		}
+
+		// Pages past the end are empty. Checking that first keeps the
+		// multiplication from overflowing.
+		if number-1 > total/p.Limit {
+			p.Offset = total
+		} else {
+			p.Offset = (number - 1) * p.Limit
+		}
+	case StyleCursor:
+		if cursor := query.Get(param(ParamCursor)); cursor != "" {
+			if p.Offset, err = DecodeCursor(cursor); err != nil {
+				return Page{}, err
+			}
+		}
+	default:
+		if p.Offset, err = intParam(query, param(ParamOffset), 0, 0); err != nil {
+			return Page{}, err
+		}
+	}
+
+	p.Offset = min(max(p.Offset, 0), total)
+
+	link := func(offset int) url.Values {
+		values := cloneValues(query)
+		switch opts.Style {
+		case StylePage:
+			values.Set(param(ParamPage), strconv.Itoa(offset/p.Limit+1))
+		case StyleCursor:
+			values.Set(param(ParamCursor), EncodeCursor(offset))
+		default:
+			values.Set(param(ParamOffset), strconv.Itoa(offset))
+		}
+		return values
+	}
+
+	p.First = link(0)
+	if p.Offset > 0 {
+		prev := max(p.Offset-p.Limit, 0)
+		p.Prev = link(prev)
+		p.PrevCursor = EncodeCursor(prev)
+	}
+	if p.End() < total {
+		p.Next = link(p.End())
+		p.NextCursor = EncodeCursor(p.End())
+	}
+	if total > 0 && opts.Style != StyleCursor {
+		p.Last = link((total - 1) / p.Limit * p.Limit)
+	}
+
+	return p, nil
+}
+
+func intParam(query url.Values, name string, fallback, minimum int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < minimum {
+		return 0, fmt.Errorf("%w: %s=%q", ErrInvalidParam, name, value)
+	}
+
+	return n, nil
+}
+
+// EncodeCursor hides an offset in an opaque cursor, like real APIs do.
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func DecodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), cursorPrefix))
+	if err != nil || !strings.HasPrefix(string(data), cursorPrefix) || offset < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+
+	return offset, nil
+}
+
+func cloneValues(values url.Values) url.Values {
+	clone := make(url.Values, len(values))
+	for k, v := range values {
+		clone[k] = append([]string(nil), v...)
+	}
+
+	return clone
+}
@@ -0,0 +1,114 @@
+package paginate
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	query := func(t *testing.T, raw string) url.Values {
+		values, err := url.ParseQuery(raw)
+		require.NoError(t, err)
+		return values
+	}
+
+	t.Run("Offset and limit", func(t *testing.T) {
+		p, err := Paginate(Options{DefaultLimit: 10}, query(t, "offset=10&limit=5&q=x"), 22)
+		require.NoError(t, err)
+
+		require.Equal(t, 10, p.Offset)
+		require.Equal(t, 15, p.End())
+		require.Equal(t, "limit=5&offset=15&q=x", p.Next.Encode())
+		require.Equal(t, "limit=5&offset=5&q=x", p.Prev.Encode())
+		require.Equal(t, "limit=5&offset=0&q=x", p.First.Encode())
+		require.Equal(t, "limit=5&offset=20&q=x", p.Last.Encode())
+	})
+
+	t.Run("Defaults and maximum", func(t *testing.T) {
+		p, err := Paginate(Options{DefaultLimit: 10}, nil, 22)
+		require.NoError(t, err)
+		require.Equal(t, 10, p.Limit)
+		require.Nil(t, p.Prev)
+
+		p, err = Paginate(Options{DefaultLimit: 10, MaxLimit: 20}, query(t, "limit=500"), 22)
+		require.NoError(t, err)
+		require.Equal(t, 20, p.Limit)
+	})
+
+	t.Run("Pages with renamed parameters", func(t *testing.T) {
+		opts := Options{Style: StylePage, DefaultLimit: 10, Params: map[string]string{ParamSize: "per_page"}}
+
+		p, err := Paginate(opts, query(t, "page=3&per_page=10"), 22)
+		require.NoError(t, err)
+
+		require.Equal(t, 20, p.Offset)
+		require.Equal(t, 22, p.End())
+		require.Equal(t, 3, p.Number())
+		require.Nil(t, p.Next)
+		require.Equal(t, "page=2&per_page=10", p.Prev.Encode())
+		require.Equal(t, "page=3&per_page=10", p.Last.Encode())
+	})
+
+	t.Run("Cursors", func(t *testing.T) {
+		opts := Options{Style: StyleCursor, DefaultLimit: 10}
+
+		p, err := Paginate(opts, nil, 22)
+		require.NoError(t, err)
+		require.Empty(t, p.PrevCursor)
+
+		p, err = Paginate(opts, url.Values{"cursor": {p.NextCursor}}, 22)
+		require.NoError(t, err)
+		require.Equal(t, 10, p.Offset)
+		require.Nil(t, p.Last)
+		require.Equal(t, p.NextCursor, p.Next.Get("cursor"))
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		_, err := Paginate(Options{}, query(t, "limit=0"), 5)
+		require.ErrorIs(t, err, ErrInvalidParam)
+
+		_, err = Paginate(Options{}, query(t, "offset=-1"), 5)
+		require.ErrorIs(t, err, ErrInvalidParam)
+
+		_, err = Paginate(Options{Style: StyleCursor}, query(t, "cursor=nope"), 5)
+		require.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("Offsets past the end give an empty page", func(t *testing.T) {
+		p, err := Paginate(Options{DefaultLimit: 10}, query(t, "offset=50"), 22)
+		require.NoError(t, err)
+		require.Equal(t, 22, p.Offset)
+		require.Equal(t, 22, p.End())
+		require.Nil(t, p.Next)
+	})
+	t.Run("Huge pages and limits do not overflow", func(t *testing.T) {
+		p, err := Paginate(Options{Style: StylePage, DefaultLimit: 10}, query(t, "page=1000000000000000000&size=10"), 22)
+		require.NoError(t, err)
+		require.Equal(t, 22, p.Offset)
+		require.Equal(t, 22, p.End())
+		require.Nil(t, p.Next)
+
+		p, err = Paginate(Options{Style: StylePage, DefaultLimit: 10}, query(t, "page=3&size=9223372036854775807"), 22)
+		require.NoError(t, err)
+		require.Equal(t, 22, p.Offset)
+
+		p, err = Paginate(Options{DefaultLimit: 10}, query(t, "offset=5&limit=9223372036854775807"), 22)
+		require.NoError(t, err)
+		require.Equal(t, 5, p.Offset)
+		require.Equal(t, 22, p.End())
+
+		p, err = Paginate(Options{DefaultLimit: 10}, query(t, "offset=9223372036854775807&limit=9223372036854775807"), 22)
+		require.NoError(t, err)
+		require.Equal(t, 22, p.Offset)
+		require.Equal(t, 22, p.End())
+	})
+
+	t.Run("The maximum applies before the offset", func(t *testing.T) {
+		p, err := Paginate(Options{Style: StylePage, DefaultLimit: 10, MaxLimit: 5}, query(t, "page=2&size=100"), 22)
+		require.NoError(t, err)
+		require.Equal(t, 5, p.Offset)
+		require.Equal(t, 10, p.End())
+	})
+}
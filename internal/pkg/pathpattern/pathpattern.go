@@ -0,0 +1,175 @@
+// Package pathpattern extends ServeMux patterns with typed wildcards such
+// as {id:int} or {slug:[a-z-]+}, which are compiled to regular expressions.
+package pathpattern
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrUnclosedWildcard = errors.New("unclosed wildcard")
+
+// types are the named constraints. Anything else is taken as a regular
+// expression.
+var types = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+type Wildcard struct {
+	Name       string
+	Constraint string
+	// Rest is set for {name...}, which matches the remainder of the path.
+	Rest bool
+}
+
+type segment struct {
+	literal  string
+	wildcard *Wildcard
+	end      bool
+}
+
+// Pattern is a parsed route pattern like "GET /users/{id:int}".
+type Pattern struct {
+	Method   string
+	segments []segment
+}
+
+func Parse(pattern string) (*Pattern, error) {
+	p := &Pattern{}
+
+	path := pattern
+	if method, rest, found := strings.Cut(pattern, " "); found {
+		p.Method, path = method, strings.TrimSpace(rest)
+	}
+
+	for len(path) > 0 {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			p.segments = append(p.segments, segment{literal: path})
+			break
+		}
+
+		if start > 0 {
+			p.segments = append(p.segments, segment{literal: path[:start]})
+		}
+
+		end := closingBrace(path, start)
+		if end < 0 {
+			return nil, fmt.Errorf("%w in %q", ErrUnclosedWildcard, pattern)
+		}
+
+		content := path[start+1 : end]
+		if content == "$" {
+			p.segments = append(p.segments, segment{end: true})
+		} else {
+			p.segments = append(p.segments, segment{wildcard: parseWildcard(content)})
+		}
+
+		path = path[end+1:]
+	}
+
+	return p, nil
+}
+
+// closingBrace finds the brace closing the one at start, so that
+// constraints like [0-9]{3} stay intact.
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func parseWildcard(content string) *Wildcard {
+	name, constraint, _ := strings.Cut(content, ":")
+
+	w := &Wildcard{Name: name, Constraint: constraint}
+	if strings.HasSuffix(w.Name, "...") {
+		w.Name = strings.TrimSuffix(w.Name, "...")
+		w.Rest = true
+	}
+
+	return w
+}
+
+func (p *Pattern) Wildcards() []Wildcard {
+	var wildcards []Wildcard
+	for _, s := range p.segments {
+		if s.wildcard != nil {
+			wildcards = append(wildcards, *s.wildcard)
+		}
+	}
+
+	return wildcards
+}
+
+// Typed reports whether a wildcard has a constraint, which ServeMux cannot
+// handle.
+func (p *Pattern) Typed() bool {
+	for _, w := range p.Wildcards() {
+		if w.Constraint != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Regexp compiles the path of the pattern, with a named group for every
+// wildcard. Like in ServeMux, a trailing slash matches the whole subtree.
+func (p *Pattern) Regexp() (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	subtree := false
+	for i, s := range p.segments {
+		switch {
+		case s.end:
+			b.WriteString("$")
+			return regexp.Compile(b.String())
+		case s.wildcard != nil:
+			fmt.Fprintf(&b, "(?P<%s>%s)", s.wildcard.Name, s.wildcard.expr())
+		default:
+			b.WriteString(regexp.QuoteMeta(s.literal))
+			subtree = i == len(p.segments)-1 && strings.HasSuffix(s.literal, "/")
+		}
+	}
+
+	if !subtree {
+		b.WriteString("$")
+	}
+
+	return regexp.Compile(b.String())
+}
+
+func (w *Wildcard) expr() string {
+	if expr, ok := types[w.Constraint]; ok {
+		return expr
+	}
+
+	if w.Constraint != "" {
+		return w.Constraint
+	}
+
+	if w.Rest {
+		return `.*`
+	}
+
+	return `[^/]+`
+}
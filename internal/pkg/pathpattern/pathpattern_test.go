@@ -0,0 +1,77 @@
+package pathpattern
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPattern(t *testing.T) {
+	compile := func(t *testing.T, pattern string) (*Pattern, func(string) map[string]string) {
+		p, err := Parse(pattern)
+		require.NoError(t, err)
+
+		re, err := p.Regexp()
+		require.NoError(t, err)
+
+		return p, func(path string) map[string]string {
+			m := re.FindStringSubmatch(path)
+			if m == nil {
+				return nil
+			}
+
+			values := make(map[string]string)
+			for i, name := range re.SubexpNames() {
+				if name != "" {
+					values[name] = m[i]
+				}
+			}
+
+			return values
+		}
+	}
+
+	t.Run("Splits off the method", func(t *testing.T) {
+		p, _ := compile(t, "GET /users/{id}")
+		require.Equal(t, "GET", p.Method)
+		require.False(t, p.Typed())
+	})
+
+	t.Run("Typed wildcards only match their type", func(t *testing.T) {
+		p, match := compile(t, "/users/{id:int}")
+		require.True(t, p.Typed())
+
+		require.Equal(t, map[string]string{"id": "42"}, match("/users/42"))
+		require.Nil(t, match("/users/me"))
+		require.Nil(t, match("/users/42/orders"))
+	})
+
+	t.Run("Constraints may be regular expressions with braces", func(t *testing.T) {
+		_, match := compile(t, "/codes/{code:[0-9]{3}}.{ext:json|xml}")
+
+		require.Equal(t, map[string]string{"code": "404", "ext": "json"}, match("/codes/404.json"))
+		require.Nil(t, match("/codes/4040.json"))
+		require.Nil(t, match("/codes/404.yaml"))
+	})
+
+	t.Run("Rest wildcards match the remaining path", func(t *testing.T) {
+		p, match := compile(t, "/files/{path...}")
+		require.Equal(t, []Wildcard{{Name: "path", Rest: true}}, p.Wildcards())
+
+		require.Equal(t, map[string]string{"path": "a/b.txt"}, match("/files/a/b.txt"))
+	})
+
+	t.Run("Trailing slashes match the subtree unless anchored", func(t *testing.T) {
+		_, match := compile(t, "/v{version:int}/")
+		require.NotNil(t, match("/v1/anything"))
+
+		_, match = compile(t, "/v{version:int}/{$}")
+		require.NotNil(t, match("/v1/"))
+		require.Nil(t, match("/v1/anything"))
+	})
+
+	t.Run("Unclosed wildcards are rejected", func(t *testing.T) {
+		_, err := Parse("/users/{id")
+		require.ErrorIs(t, err, ErrUnclosedWildcard)
+	})
+}
@@ -0,0 +1,139 @@
+package protoload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// FromProtoFiles compiles .proto files and returns a registry holding them
+// together with everything they import. Files are named relative to the
+// import path holding them, and the directory of a file outside every
+// import path is used as an extra one. Files that do not exist are looked
+// up in the import paths by name.
+func FromProtoFiles(ctx context.Context, importPaths []string, files []string) (*protoregistry.Files, error) {
+	importPaths = append([]string(nil), importPaths...)
+	names := make([]string, 0, len(files))
+	seen := make(map[string]bool)
+
+	for _, f := range files {
+		if name, ok := relativeName(importPaths, f); ok {
+			names = append(names, name)
+			continue
+		}
+
+		if _, err := os.Stat(f); err != nil && len(importPaths) > 0 {
+			names = append(names, filepath.ToSlash(f))
+			continue
+		}
+
+		dir := filepath.Dir(f)
+		if !seen[dir] {
+			seen[dir] = true
+			importPaths = append(importPaths, dir)
+		}
+		names = append(names, filepath.Base(f))
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: importPaths,
+		}),
+	}
+
+	compiled, err := compiler.Compile(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w", err)
+	}
+
+	registry := new(protoregistry.Files)
+	for _, fd := range compiled {
+		if err := register(registry, fd); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// relativeName names file after the first import path that contains it,
+// the way imports inside .proto files refer to it.
+func relativeName(importPaths []string, file string) (string, bool) {
+	for _, dir := range importPaths {
+		rel, err := filepath.Rel(dir, file)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel), true
+		}
+	}
+
+	return "", false
+}
+
+// FromDescriptorSet reads a serialized FileDescriptorSet, as produced by
+// `protoc --include_imports --descriptor_set_out`.
+func FromDescriptorSet(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read descriptor set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("decode descriptor set: %w", err)
+	}
+
+	registry, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("build registry: %w", err)
+	}
+
+	return registry, nil
+}
+
+// Methods indexes every method of every service in registry by its full
+// gRPC name, e.g. "/helloworld.Greeter/SayHello".
+func Methods(registry *protoregistry.Files) map[string]protoreflect.MethodDescriptor {
+	methods := make(map[string]protoreflect.MethodDescriptor)
+
+	registry.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			sd := services.Get(i)
+			for j := 0; j < sd.Methods().Len(); j++ {
+				md := sd.Methods().Get(j)
+				methods[fmt.Sprintf("/%s/%s", sd.FullName(), md.Name())] = md
+			}
+		}
+		return true
+	})
+
+	return methods
+}
+
+func register(registry *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := registry.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := register(registry, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+
+	if err := registry.RegisterFile(fd); err != nil {
+		return fmt.Errorf("register %s: %w", fd.Path(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package protoload
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaato137/quickrest/internal/pkg/testfile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromProtoFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	testfile.Write(t, dir, "protos/types.proto", `syntax = "proto3"; package helloworld; message HelloRequest { string name = 1; }`)
+	testfile.Write(t, dir, "protos/greeter.proto", `syntax = "proto3"; package helloworld; import "types.proto";
+message HelloReply { string message = 1; }
+service Greeter { rpc SayHello (HelloRequest) returns (HelloReply); }`)
+
+	protos := filepath.Join(dir, "protos")
+
+	for name, args := range map[string]struct {
+		importPaths []string
+		files       []string
+	}{
+		"Files inside an import path":  {[]string{protos}, []string{filepath.Join(protos, "greeter.proto")}},
+		"Files without import paths":   {nil, []string{filepath.Join(protos, "greeter.proto")}},
+		"Names relative to the import": {[]string{protos}, []string{"greeter.proto"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			registry, err := FromProtoFiles(context.Background(), args.importPaths, args.files)
+			require.NoError(t, err)
+
+			_, ok := Methods(registry)["/helloworld.Greeter/SayHello"]
+			require.True(t, ok)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Package recording reads and writes the request logs of the recorder.
+//
+// An entry looks like a plain HTTP exchange: a title line, headers, a blank
+// line and a body framed by Content-Length, optionally followed by the
+// response that was sent:
+//
+//	POST /users
+//	Content-Length: 13
+//	Content-Type: application/json
+//
+//	{"name":"a"}
+//	HTTP/1.1 201 Created
+//	Content-Length: 9
+//
+//	{"id": 1}
+//
+// Entries are separated by a blank line.
+package recording
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrMalformed = errors.New("malformed recording")
+
+// Redacted stands in for the values of credential headers.
+const Redacted = "[redacted]"
+
+// CredentialHeaders carry secrets, which are redacted unless the recorder
+// is asked to keep them.
+var CredentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Entry is a recorded request. Title is "METHOD URL" for HTTP requests,
+// and the full method name for gRPC calls.
+type Entry struct {
+	Title  string
+	Header http.Header
+	Body   []byte
+
+	// Response is what was sent back, if it was recorded.
+	Response *Response
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Request splits the title of an HTTP entry into its method and URL. It
+// reports false for entries that are not HTTP requests.
+func (e Entry) Request() (method, target string, ok bool) {
+	method, target, ok = strings.Cut(e.Title, " ")
+	if !ok || method == "" || !strings.HasPrefix(target, "/") {
+		return "", "", false
+	}
+
+	return method, target, true
+}
+
+// Redact returns a copy of header with the values of credential headers
+// replaced by Redacted.
+func Redact(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, k := range CredentialHeaders {
+		if len(redacted.Values(k)) > 0 {
+			redacted[textproto.CanonicalMIMEHeaderKey(k)] = []string{Redacted}
+		}
+	}
+
+	return redacted
+}
+
+// Write appends e to w.
+func Write(w io.Writer, e Entry) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "%s\n", e.Title)
+	writeMessage(bw, e.Header, e.Body)
+
+	if resp := e.Response; resp != nil {
+		fmt.Fprintf(bw, "HTTP/1.1 %d %s\n", resp.Status, http.StatusText(resp.Status))
+		writeMessage(bw, resp.Header, resp.Body)
+	}
+
+	bw.WriteString("\n")
+
+	return bw.Flush()
+}
+
+func writeMessage(w *bufio.Writer, header http.Header, body []byte) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		if textproto.CanonicalMIMEHeaderKey(k) != "Content-Length" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "Content-Length: %d\n", len(body))
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(w, "%s: %s\n", k, v)
+		}
+	}
+
+	w.WriteString("\n")
+	w.Write(body)
+	w.WriteString("\n")
+}
+
+// Read parses all entries of a log.
+func Read(r io.Reader) ([]Entry, error) {
+	br := bufio.NewReader(r)
+	tp := textproto.NewReader(br)
+
+	var entries []Entry
+	for {
+		title, err := nextLine(tp)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+
+		entry := Entry{Title: title}
+		if entry.Header, entry.Body, err = readMessage(br, tp); err != nil {
+			return entries, fmt.Errorf("%w: entry %d: %w", ErrMalformed, len(entries)+1, err)
+		}
+
+		if next, err := br.Peek(len("HTTP/")); err == nil && string(next) == "HTTP/" {
+			if entry.Response, err = readResponse(br, tp); err != nil {
+				return entries, fmt.Errorf("%w: entry %d: %w", ErrMalformed, len(entries)+1, err)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+// nextLine skips the blank lines between entries.
+func nextLine(tp *textproto.Reader) (string, error) {
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		if line != "" {
+			return line, nil
+		}
+	}
+}
+
+func readResponse(br *bufio.Reader, tp *textproto.Reader) (*Response, error) {
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	_, code, _ := strings.Cut(line, " ")
+	code, _, _ = strings.Cut(code, " ")
+	status, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, fmt.Errorf("status line %q", line)
+	}
+
+	header, body, err := readMessage(br, tp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Status: status, Header: header, Body: body}, nil
+}
+
+func readMessage(br *bufio.Reader, tp *textproto.Reader) (http.Header, []byte, error) {
+	mime, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read header: %w", err)
+	}
+	header := http.Header(mime)
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	header.Del("Content-Length")
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(br, body); err != nil {
+		return nil, nil, fmt.Errorf("read body: %w", err)
+	}
+
+	if b, err := br.ReadByte(); err != nil || b != '\n' {
+		return nil, nil, fmt.Errorf("body is longer than its Content-Length")
+	}
+
+	return header, body, nil
+}
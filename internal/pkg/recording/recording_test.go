@@ -0,0 +1,99 @@
+package recording
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Entry{
+		Title:  "POST /users?team=1",
+		Header: http.Header{"Content-Type": {"application/json"}, "Accept": {"a", "b"}},
+		Body:   []byte("{\"name\":\"a\"}\n\n"),
+		Response: &Response{
+			Status: http.StatusCreated,
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   []byte(`{"id": 1}`),
+		},
+	}))
+
+	require.Equal(t, "POST /users?team=1\n"+
+		"Content-Length: 14\nAccept: a\nAccept: b\nContent-Type: application/json\n\n"+
+		"{\"name\":\"a\"}\n\n\n"+
+		"HTTP/1.1 201 Created\nContent-Length: 9\nContent-Type: application/json\n\n"+
+		"{\"id\": 1}\n"+
+		"\n", buf.String())
+}
+
+func TestRead(t *testing.T) {
+	t.Run("Entries round trip", func(t *testing.T) {
+		entries := []Entry{
+			{
+				Title:  "POST /users",
+				Header: http.Header{"Content-Type": {"text/plain"}},
+				Body:   []byte("HTTP/1.1 200 OK\n\nnot a response"),
+				Response: &Response{
+					Status: http.StatusTeapot,
+					Header: http.Header{"X-Id": {"7"}},
+					Body:   []byte("short and stout"),
+				},
+			},
+			{Title: "GET /users/1", Header: http.Header{}, Body: []byte{}},
+			{Title: "/greeter.Greeter/SayHello", Header: http.Header{}, Body: []byte(`{"name":"a"}`)},
+		}
+
+		var buf bytes.Buffer
+		for _, e := range entries {
+			require.NoError(t, Write(&buf, e))
+		}
+
+		read, err := Read(&buf)
+		require.NoError(t, err)
+		require.Equal(t, entries, read)
+	})
+
+	t.Run("Bodies must match their length", func(t *testing.T) {
+		_, err := Read(strings.NewReader("GET /\nContent-Length: 2\n\nabc\n\n"))
+		require.ErrorIs(t, err, ErrMalformed)
+
+		_, err = Read(strings.NewReader("GET /\n\nabc\n\n"))
+		require.ErrorIs(t, err, ErrMalformed)
+	})
+
+	t.Run("Entries before a malformed one are returned", func(t *testing.T) {
+		entries, err := Read(strings.NewReader("GET /a\nContent-Length: 0\n\n\n\nPOST /b\n{\"old\": true}\n\n"))
+		require.ErrorIs(t, err, ErrMalformed)
+		require.Len(t, entries, 1)
+		require.Equal(t, "GET /a", entries[0].Title)
+	})
+}
+
+func TestRedact(t *testing.T) {
+	header := http.Header{
+		"Authorization": {"Bearer secret"},
+		"Cookie":        {"a=1", "b=2"},
+		"Accept":        {"application/json"},
+	}
+
+	require.Equal(t, http.Header{
+		"Authorization": {Redacted},
+		"Cookie":        {Redacted},
+		"Accept":        {"application/json"},
+	}, Redact(header))
+	require.Equal(t, "Bearer secret", header.Get("Authorization"))
+}
+
+func TestEntryRequest(t *testing.T) {
+	method, target, ok := Entry{Title: "GET /users?page=2"}.Request()
+	require.True(t, ok)
+	require.Equal(t, "GET", method)
+	require.Equal(t, "/users?page=2", target)
+
+	_, _, ok = Entry{Title: "/greeter.Greeter/SayHello"}.Request()
+	require.False(t, ok)
+}
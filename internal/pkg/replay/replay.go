@@ -0,0 +1,323 @@
+// Package replay sends recorded requests to a real service and compares
+// its answers with the recorded responses.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kaato137/quickrest/internal/pkg/recording"
+	"github.com/kaato137/quickrest/internal/pkg/upload"
+)
+
+// maxDiffs caps the differences reported for one response.
+const maxDiffs = 10
+
+var (
+	ErrInvalidTarget = errors.New("target must be an absolute http(s) URL")
+	ErrNotHTTP       = errors.New("not an HTTP request")
+
+	// ErrNeedsCredentials is set when the target refused a request whose
+	// credentials were redacted in the recording.
+	ErrNeedsCredentials = errors.New("needs credentials")
+)
+
+// skippedHeaders are not sent again: the client sets them itself, and
+// Accept-Encoding would stop it from decoding compressed answers.
+var skippedHeaders = []string{"Content-Length", "Host", "Connection", "Accept-Encoding"}
+
+// Result is the outcome of replaying one entry.
+type Result struct {
+	Method string
+	URL    string
+	Status int
+
+	// Compared is set when the answer was checked against a recorded
+	// response, and Diffs lists how they differ.
+	Compared bool
+	Diffs    []string
+
+	// Err is set when the request could not be sent. Entries that are not
+	// HTTP requests fail with ErrNotHTTP, and those refused for want of
+	// their redacted credentials with ErrNeedsCredentials.
+	Err error
+}
+
+// Passed reports whether the request was sent and matched the recording,
+// if it was compared.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+type Replayer struct {
+	target  string
+	client  *http.Client
+	compare bool
+	header  http.Header
+}
+
+// New creates a replayer sending requests to target, which may include a
+// path prefix.
+func New(target string) (*Replayer, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTarget, target)
+	}
+
+	client := &http.Client{
+		// Redirects are answers too, and are compared as such.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &Replayer{target: strings.TrimSuffix(u.String(), "/"), client: client}, nil
+}
+
+// WithClient sends requests with c. Redirects are followed as c decides.
+func (rp *Replayer) WithClient(c *http.Client) *Replayer {
+	rp.client = c
+
+	return rp
+}
+
+// WithCompare makes Replay check answers against the recorded responses.
+func (rp *Replayer) WithCompare(compare bool) *Replayer {
+	rp.compare = compare
+
+	return rp
+}
+
+// WithHeader sets headers on every request, replacing the recorded ones.
+// It is how redacted credentials are supplied again.
+func (rp *Replayer) WithHeader(header http.Header) *Replayer {
+	rp.header = header
+
+	return rp
+}
+
+// Replay sends entry to the target.
+func (rp *Replayer) Replay(ctx context.Context, entry recording.Entry) Result {
+	method, target, ok := entry.Request()
+	if !ok {
+		return Result{URL: entry.Title, Err: ErrNotHTTP}
+	}
+	result := Result{Method: method, URL: target}
+
+	req, err := rp.newRequest(ctx, method, target, entry)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	resp, err := rp.client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.Err = fmt.Errorf("read response: %w", err)
+		return result
+	}
+	result.Status = resp.StatusCode
+
+	if missing := rp.missingCredentials(entry); len(missing) > 0 && refused(resp.StatusCode, entry.Response) {
+		result.Err = fmt.Errorf("%w: %s was redacted", ErrNeedsCredentials, strings.Join(missing, ", "))
+		return result
+	}
+
+	if rp.compare && entry.Response != nil {
+		result.Compared = true
+		result.Diffs = Compare(entry.Response, resp.StatusCode, resp.Header, body)
+	}
+
+	return result
+}
+
+func (rp *Replayer) newRequest(ctx context.Context, method, target string, entry recording.Entry) (*http.Request, error) {
+	header := entry.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	for _, k := range skippedHeaders {
+		header.Del(k)
+	}
+	// Redacted credentials would only be rejected.
+	for _, k := range rp.missingCredentials(entry) {
+		header.Del(k)
+	}
+	for k, values := range rp.header {
+		header[http.CanonicalHeaderKey(k)] = values
+	}
+
+	// The recorder keeps a summary of multipart bodies, which is turned
+	// back into a body from the saved files.
+	body := entry.Body
+	if upload.IsMultipart(header.Get("Content-Type")) {
+		form, err := upload.ParseSummary(string(body))
+		if err == nil {
+			contentType, encoded, err := form.Encode()
+			if err != nil {
+				return nil, fmt.Errorf("encode upload: %w", err)
+			}
+			header.Set("Content-Type", contentType)
+			body = encoded
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rp.target+target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+
+	return req, nil
+}
+
+// missingCredentials lists the credential headers that were redacted in
+// the recording and are not supplied by WithHeader.
+func (rp *Replayer) missingCredentials(entry recording.Entry) []string {
+	var missing []string
+	for _, k := range recording.CredentialHeaders {
+		if slices.Contains(entry.Header.Values(k), recording.Redacted) && rp.header.Get(k) == "" {
+			missing = append(missing, k)
+		}
+	}
+
+	return missing
+}
+
+// refused reports whether status turns the request away for its
+// credentials, unless that is what was recorded.
+func refused(status int, want *recording.Response) bool {
+	if status != http.StatusUnauthorized && status != http.StatusForbidden {
+		return false
+	}
+
+	return want == nil || want.Status != status
+}
+
+// Compare lists how an answer differs from the recorded response: its
+// status, media type and body. JSON bodies are compared by value.
+func Compare(want *recording.Response, status int, header http.Header, body []byte) []string {
+	var diffs []string
+
+	if want.Status != status {
+		diffs = append(diffs, fmt.Sprintf("status: want %d, got %d", want.Status, status))
+	}
+
+	wantType, gotType := mediaType(want.Header.Get("Content-Type")), mediaType(header.Get("Content-Type"))
+	if wantType != gotType {
+		diffs = append(diffs, fmt.Sprintf("Content-Type: want %q, got %q", wantType, gotType))
+	}
+
+	var wantJSON, gotJSON any
+	if json.Unmarshal(want.Body, &wantJSON) == nil && json.Unmarshal(body, &gotJSON) == nil {
+		diffJSON("body", wantJSON, gotJSON, &diffs)
+	} else if !bytes.Equal(want.Body, body) {
+		diffs = append(diffs, fmt.Sprintf("body: want %s, got %s", excerpt(want.Body), excerpt(body)))
+	}
+
+	if len(diffs) > maxDiffs {
+		diffs = append(diffs[:maxDiffs], fmt.Sprintf("... and %d more", len(diffs)-maxDiffs))
+	}
+
+	return diffs
+}
+
+// diffJSON walks both values and reports the paths where they differ.
+func diffJSON(path string, want, got any, diffs *[]string) {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case !inGot:
+				*diffs = append(*diffs, fmt.Sprintf("%s.%s: missing", path, k))
+			case !inWant:
+				*diffs = append(*diffs, fmt.Sprintf("%s.%s: unexpected %s", path, k, encode(gv)))
+			default:
+				diffJSON(path+"."+k, wv, gv, diffs)
+			}
+		}
+		return
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			break
+		}
+
+		if len(w) != len(g) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: want %d items, got %d", path, len(w), len(g)))
+		}
+		for i := range min(len(w), len(g)) {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: want %s, got %s", path, encode(want), encode(got)))
+	}
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+
+	return mt
+}
+
+// excerptLimit caps the values quoted in a diff line.
+const excerptLimit = 80
+
+func encode(v any) string {
+	b, _ := json.Marshal(v)
+	if len(b) > excerptLimit {
+		return string(b[:excerptLimit]) + "..."
+	}
+
+	return string(b)
+}
+
+// excerpt quotes the start of a body for a diff line.
+func excerpt(b []byte) string {
+	if len(b) > excerptLimit {
+		return fmt.Sprintf("%q...", b[:excerptLimit])
+	}
+
+	return fmt.Sprintf("%q", b)
+}
@@ -0,0 +1,149 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kaato137/quickrest/internal/pkg/recording"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplay(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(body)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"id": 1, "name": "Ann", "tags": ["a"]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	rp, err := New(srv.URL + "/api/")
+	require.NoError(t, err)
+	rp.WithCompare(true)
+
+	entry := recording.Entry{
+		Title:  "POST /users?team=1",
+		Header: http.Header{"Content-Type": {"application/json"}, "X-Token": {"t"}, "Content-Length": {"99"}},
+		Body:   []byte(`{"name":"Ann"}`),
+	}
+
+	t.Run("Requests are sent as recorded", func(t *testing.T) {
+		result := rp.Replay(context.Background(), entry)
+		require.NoError(t, result.Err)
+		require.Equal(t, http.StatusCreated, result.Status)
+		require.False(t, result.Compared)
+		require.True(t, result.Passed())
+
+		require.Equal(t, http.MethodPost, got.Method)
+		require.Equal(t, "/api/users", got.URL.Path)
+		require.Equal(t, "team=1", got.URL.RawQuery)
+		require.Equal(t, "t", got.Header.Get("X-Token"))
+		require.EqualValues(t, 14, got.ContentLength)
+		require.Equal(t, `{"name":"Ann"}`, gotBody)
+	})
+
+	t.Run("Matching responses pass", func(t *testing.T) {
+		entry.Response = &recording.Response{
+			Status: http.StatusCreated,
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   []byte(`{"tags": ["a"], "name": "Ann", "id": 1}`),
+		}
+
+		result := rp.Replay(context.Background(), entry)
+		require.True(t, result.Compared)
+		require.True(t, result.Passed())
+	})
+
+	t.Run("Differences are listed", func(t *testing.T) {
+		entry.Response = &recording.Response{
+			Status: http.StatusOK,
+			Header: http.Header{"Content-Type": {"text/plain"}},
+			Body:   []byte(`{"id": 2, "tags": ["a", "b"], "email": "x"}`),
+		}
+
+		result := rp.Replay(context.Background(), entry)
+		require.False(t, result.Passed())
+		require.Equal(t, []string{
+			`status: want 200, got 201`,
+			`Content-Type: want "text/plain", got "application/json"`,
+			`body.email: missing`,
+			`body.id: want 2, got 1`,
+			`body.name: unexpected "Ann"`,
+			`body.tags: want 2 items, got 1`,
+		}, result.Diffs)
+	})
+
+	t.Run("Redacted credentials are not sent", func(t *testing.T) {
+		redacted := entry
+		redacted.Header = http.Header{"Authorization": {recording.Redacted}, "Cookie": {"a=1"}}
+
+		result := rp.Replay(context.Background(), redacted)
+		require.NoError(t, result.Err)
+		require.Empty(t, got.Header.Values("Authorization"))
+		require.Equal(t, "a=1", got.Header.Get("Cookie"))
+	})
+
+	t.Run("Other entries are skipped", func(t *testing.T) {
+		result := rp.Replay(context.Background(), recording.Entry{Title: "/greeter.Greeter/SayHello"})
+		require.ErrorIs(t, result.Err, ErrNotHTTP)
+	})
+}
+
+func TestCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	entry := recording.Entry{
+		Title:    "GET /me",
+		Header:   http.Header{"Authorization": {recording.Redacted}},
+		Response: &recording.Response{Status: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}}, Body: []byte("ok")},
+	}
+
+	t.Run("Refused requests need credentials", func(t *testing.T) {
+		rp, err := New(srv.URL)
+		require.NoError(t, err)
+
+		result := rp.WithCompare(true).Replay(context.Background(), entry)
+		require.ErrorIs(t, result.Err, ErrNeedsCredentials)
+		require.ErrorContains(t, result.Err, "Authorization")
+		require.Equal(t, http.StatusUnauthorized, result.Status)
+	})
+
+	t.Run("Credentials can be supplied", func(t *testing.T) {
+		rp, err := New(srv.URL)
+		require.NoError(t, err)
+
+		rp.WithCompare(true).WithHeader(http.Header{"authorization": {"Bearer fresh"}})
+		result := rp.Replay(context.Background(), entry)
+		require.NoError(t, result.Err)
+		require.True(t, result.Passed())
+	})
+}
+
+func TestCompare(t *testing.T) {
+	t.Run("Text bodies are compared as is", func(t *testing.T) {
+		want := &recording.Response{Status: http.StatusOK, Header: http.Header{}, Body: []byte("pong")}
+		require.Empty(t, Compare(want, http.StatusOK, http.Header{}, []byte("pong")))
+		require.Equal(t, []string{`body: want "pong", got "ping"`}, Compare(want, http.StatusOK, http.Header{}, []byte("ping")))
+	})
+}
+
+func TestNew(t *testing.T) {
+	for _, target := range []string{"localhost:8080", "/records", "ftp://example.com"} {
+		_, err := New(target)
+		require.ErrorIs(t, err, ErrInvalidTarget, target)
+	}
+}
@@ -0,0 +1,117 @@
+// Package scenario keeps the current state of named state machines.
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrUnknownScenario = errors.New("unknown scenario")
+
+type Store struct {
+	mutex   sync.Mutex
+	initial map[string]string
+	states  map[string]string
+}
+
+func New() *Store {
+	return &Store{
+		initial: make(map[string]string),
+		states:  make(map[string]string),
+	}
+}
+
+// Define sets the scenarios and their initial states. Scenarios that were
+// already defined keep their current state, so that a config reload does
+// not interrupt a flow. Scenarios that are gone are forgotten.
+func (s *Store) Define(initial map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	states := make(map[string]string, len(initial))
+	for name, state := range initial {
+		if current, ok := s.states[name]; ok {
+			states[name] = current
+		} else {
+			states[name] = state
+		}
+	}
+
+	s.initial = initial
+	s.states = states
+}
+
+func (s *Store) State(name string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.states[name]
+
+	return state, ok
+}
+
+// States returns a copy of all current states.
+func (s *Store) States() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	states := make(map[string]string, len(s.states))
+	for name, state := range s.states {
+		states[name] = state
+	}
+
+	return states
+}
+
+func (s *Store) Set(name, state string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.states[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownScenario, name)
+	}
+
+	s.states[name] = state
+
+	return nil
+}
+
+// Transition moves a scenario to a new state if it is still in the state
+// from. An empty from matches any state.
+func (s *Store) Transition(name, from, to string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, ok := s.states[name]
+	if !ok || (from != "" && current != from) {
+		return false
+	}
+
+	s.states[name] = to
+
+	return true
+}
+
+func (s *Store) Reset(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	initial, ok := s.initial[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownScenario, name)
+	}
+
+	s.states[name] = initial
+
+	return nil
+}
+
+func (s *Store) ResetAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for name, initial := range s.initial {
+		s.states[name] = initial
+	}
+}
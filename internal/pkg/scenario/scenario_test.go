@@ -0,0 +1,60 @@
+package scenario
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("Scenarios start in their initial state", func(t *testing.T) {
+		s := New()
+		s.Define(map[string]string{"order": "empty"})
+
+		state, ok := s.State("order")
+		require.True(t, ok)
+		require.Equal(t, "empty", state)
+	})
+
+	t.Run("Transitions only happen from the required state", func(t *testing.T) {
+		s := New()
+		s.Define(map[string]string{"order": "empty"})
+
+		require.False(t, s.Transition("order", "created", "paid"))
+		require.True(t, s.Transition("order", "empty", "created"))
+		require.True(t, s.Transition("order", "", "paid"))
+
+		state, _ := s.State("order")
+		require.Equal(t, "paid", state)
+	})
+
+	t.Run("Redefining keeps the state of known scenarios", func(t *testing.T) {
+		s := New()
+		s.Define(map[string]string{"order": "empty", "cart": "open"})
+		require.NoError(t, s.Set("order", "created"))
+
+		s.Define(map[string]string{"order": "empty", "user": "anonymous"})
+
+		require.Equal(t, map[string]string{"order": "created", "user": "anonymous"}, s.States())
+	})
+
+	t.Run("Reset goes back to the initial state", func(t *testing.T) {
+		s := New()
+		s.Define(map[string]string{"order": "empty", "cart": "open"})
+		require.NoError(t, s.Set("order", "created"))
+		require.NoError(t, s.Set("cart", "closed"))
+
+		require.NoError(t, s.Reset("order"))
+		require.Equal(t, map[string]string{"order": "empty", "cart": "closed"}, s.States())
+
+		s.ResetAll()
+		require.Equal(t, map[string]string{"order": "empty", "cart": "open"}, s.States())
+	})
+
+	t.Run("Unknown scenarios are reported", func(t *testing.T) {
+		s := New()
+
+		require.ErrorIs(t, s.Set("order", "created"), ErrUnknownScenario)
+		require.ErrorIs(t, s.Reset("order"), ErrUnknownScenario)
+	})
+}
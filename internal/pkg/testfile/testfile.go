@@ -0,0 +1,25 @@
+// Package testfile writes the files that tests load, such as configs,
+// fixtures and protos.
+package testfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Write writes content to name inside dir, creating the directories in
+// between, and returns the path of the file. It fails the test on error.
+func Write(t testing.TB, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("testfile: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("testfile: %v", err)
+	}
+
+	return path
+}
@@ -0,0 +1,340 @@
+// Package upload reads multipart/form-data bodies into field values and
+// file summaries.
+package upload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotMultipart = errors.New("not a multipart/form-data body")
+	ErrNotSummary   = errors.New("not a form summary")
+	ErrNotSaved     = errors.New("uploaded file was not saved")
+)
+
+// File describes an uploaded file. Saved is set once the file was written
+// to disk by Save.
+type File struct {
+	Field       string `json:"field"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	SHA256      string `json:"sha256"`
+	Saved       string `json:"saved,omitempty"`
+
+	header *multipart.FileHeader
+}
+
+// open reads the file from the request it came with, or from where it was
+// saved.
+func (f File) open() (io.ReadCloser, error) {
+	if f.header != nil {
+		return f.header.Open()
+	}
+	if f.Saved == "" {
+		return nil, fmt.Errorf("%w: %q", ErrNotSaved, f.Name)
+	}
+
+	return os.Open(f.Saved)
+}
+
+// Form is a parsed multipart body. Fields keep the first value sent under
+// a name, like url.Values.Get.
+type Form struct {
+	Fields map[string]string
+	Files  []File
+}
+
+// IsMultipart reports whether contentType announces a multipart/form-data
+// body.
+func IsMultipart(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// Parse reads body as the multipart/form-data announced by contentType.
+func Parse(contentType string, body []byte) (*Form, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, ErrNotMultipart
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	multipartForm, err := reader.ReadForm(int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("read form: %w", err)
+	}
+
+	return FromMultipart(multipartForm)
+}
+
+// FromMultipart summarizes a form read by net/http or mime/multipart.
+// Files are streamed to compute their checksum and are opened again by
+// Save and Encode, so they must not be removed before.
+func FromMultipart(multipartForm *multipart.Form) (*Form, error) {
+	form := &Form{Fields: make(map[string]string, len(multipartForm.Value))}
+
+	for name, values := range multipartForm.Value {
+		if len(values) > 0 {
+			form.Fields[name] = values[0]
+		}
+	}
+
+	fields := make([]string, 0, len(multipartForm.File))
+	for field := range multipartForm.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		for _, header := range multipartForm.File[field] {
+			sum, err := checksum(header)
+			if err != nil {
+				return nil, fmt.Errorf("read part %q: %w", field, err)
+			}
+
+			form.Files = append(form.Files, File{
+				Field:       field,
+				Name:        header.Filename,
+				Size:        header.Size,
+				ContentType: header.Header.Get("Content-Type"),
+				SHA256:      sum,
+				header:      header,
+			})
+		}
+	}
+
+	return form, nil
+}
+
+func checksum(header *multipart.FileHeader) (string, error) {
+	f, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Save writes the uploaded files into dir. Files are named after their
+// checksum, so the same upload is only stored once.
+func (f *Form) Save(dir string) error {
+	for i := range f.Files {
+		file := &f.Files[i]
+
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, file.SHA256[:16]+"-"+safeName(file.Name))
+		if err := file.save(path); err != nil {
+			return fmt.Errorf("save %q: %w", file.Name, err)
+		}
+
+		file.Saved = path
+	}
+
+	return nil
+}
+
+func (f File) save(path string) error {
+	src, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+// Summary describes the form one part per line, for the request recorder:
+//
+//	field title="Holiday"
+//	file photo name="beach.png" size=2048 type="image/png" sha256=...
+func (f *Form) Summary() string {
+	var b strings.Builder
+
+	for _, name := range f.fieldNames() {
+		fmt.Fprintf(&b, "field %s=%s\n", name, strconv.Quote(f.Fields[name]))
+	}
+
+	for _, file := range f.Files {
+		fmt.Fprintf(&b, "file %s name=%s size=%d type=%s sha256=%s",
+			file.Field, strconv.Quote(file.Name), file.Size, strconv.Quote(file.ContentType), file.SHA256)
+		if file.Saved != "" {
+			fmt.Fprintf(&b, " saved=%s", strconv.Quote(file.Saved))
+		}
+		b.WriteByte('\n')
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ParseSummary reads a form back from its Summary. Only the files that
+// were saved can be encoded again.
+func ParseSummary(text string) (*Form, error) {
+	form := &Form{Fields: make(map[string]string)}
+	if text == "" {
+		return form, nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		kind, rest, _ := strings.Cut(line, " ")
+		switch kind {
+		case "field":
+			name, value, ok := strings.Cut(rest, "=")
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrNotSummary, line)
+			}
+			text, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrNotSummary, line)
+			}
+			form.Fields[name] = text
+		case "file":
+			file, err := parseFileLine(rest)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrNotSummary, line)
+			}
+			form.Files = append(form.Files, file)
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrNotSummary, line)
+		}
+	}
+
+	return form, nil
+}
+
+// parseFileLine reads `photo name="beach.png" size=5 ...`.
+func parseFileLine(line string) (File, error) {
+	field, rest, _ := strings.Cut(line, " ")
+	file := File{Field: field}
+
+	for rest != "" {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return file, ErrNotSummary
+		}
+
+		if strings.HasPrefix(value, `"`) {
+			quoted, err := strconv.QuotedPrefix(value)
+			if err != nil {
+				return file, err
+			}
+			rest = strings.TrimPrefix(value[len(quoted):], " ")
+			value, _ = strconv.Unquote(quoted)
+		} else {
+			value, rest, _ = strings.Cut(value, " ")
+		}
+
+		switch key {
+		case "name":
+			file.Name = value
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return file, err
+			}
+			file.Size = size
+		case "type":
+			file.ContentType = value
+		case "sha256":
+			file.SHA256 = value
+		case "saved":
+			file.Saved = value
+		}
+	}
+
+	return file, nil
+}
+
+// Encode writes the form as a multipart body, reading the files back from
+// where they were saved.
+func (f *Form) Encode() (contentType string, body []byte, err error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for _, name := range f.fieldNames() {
+		if err := w.WriteField(name, f.Fields[name]); err != nil {
+			return "", nil, err
+		}
+	}
+
+	for _, file := range f.Files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%s; filename=%s`, strconv.Quote(file.Field), strconv.Quote(file.Name)))
+		if file.ContentType != "" {
+			header.Set("Content-Type", file.ContentType)
+		}
+
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return "", nil, err
+		}
+		src, err := file.open()
+		if err != nil {
+			return "", nil, err
+		}
+		_, err = io.Copy(part, src)
+		src.Close()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+
+	return w.FormDataContentType(), buf.Bytes(), nil
+}
+
+func (f *Form) fieldNames() []string {
+	names := make([]string, 0, len(f.Fields))
+	for name := range f.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// safeName keeps the base name of an uploaded file, dropping any directory
+// the client sent along.
+func safeName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" || name == ".." {
+		return "upload"
+	}
+
+	return name
+}
@@ -0,0 +1,149 @@
+package upload
+
+import (
+	"bytes"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBody(t *testing.T) (string, []byte) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	require.NoError(t, w.WriteField("title", "Holiday"))
+	require.NoError(t, w.WriteField("title", "ignored"))
+
+	part, err := w.CreateFormFile("photo", "../beach.png")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return w.FormDataContentType(), buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Fields and files", func(t *testing.T) {
+		contentType, body := newBody(t)
+
+		form, err := Parse(contentType, body)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"title": "Holiday"}, form.Fields)
+		require.Len(t, form.Files, 1)
+
+		file := form.Files[0]
+		require.Equal(t, "photo", file.Field)
+		require.Equal(t, "beach.png", file.Name)
+		require.EqualValues(t, 5, file.Size)
+		require.Equal(t, "application/octet-stream", file.ContentType)
+		require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", file.SHA256)
+	})
+
+	t.Run("Other bodies are rejected", func(t *testing.T) {
+		_, err := Parse("application/json", []byte(`{}`))
+		require.ErrorIs(t, err, ErrNotMultipart)
+		require.False(t, IsMultipart("application/json"))
+	})
+
+	t.Run("Files spilled to disk", func(t *testing.T) {
+		contentType, body := newBody(t)
+		_, params, err := mime.ParseMediaType(contentType)
+		require.NoError(t, err)
+
+		multipartForm, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(0)
+		require.NoError(t, err)
+		defer multipartForm.RemoveAll()
+
+		form, err := FromMultipart(multipartForm)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"title": "Holiday"}, form.Fields)
+		require.EqualValues(t, 5, form.Files[0].Size)
+		require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", form.Files[0].SHA256)
+
+		dir := t.TempDir()
+		require.NoError(t, form.Save(dir))
+		data, err := os.ReadFile(form.Files[0].Saved)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(data))
+	})
+
+	t.Run("Truncated bodies fail", func(t *testing.T) {
+		contentType, body := newBody(t)
+
+		_, err := Parse(contentType, body[:len(body)/2])
+		require.Error(t, err)
+	})
+}
+
+func TestForm(t *testing.T) {
+	contentType, body := newBody(t)
+	form, err := Parse(contentType, body)
+	require.NoError(t, err)
+
+	t.Run("Save", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "uploads")
+		require.NoError(t, form.Save(dir))
+
+		want := filepath.Join(dir, "2cf24dba5fb0a30e-beach.png")
+		require.Equal(t, want, form.Files[0].Saved)
+
+		data, err := os.ReadFile(want)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(data))
+	})
+
+	t.Run("Summary", func(t *testing.T) {
+		form.Files[0].Saved = "uploads/beach.png"
+		require.Equal(t, `field title="Holiday"
+file photo name="beach.png" size=5 type="application/octet-stream" sha256=2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824 saved="uploads/beach.png"`, form.Summary())
+	})
+}
+
+func TestParseSummary(t *testing.T) {
+	contentType, body := newBody(t)
+	form, err := Parse(contentType, body)
+	require.NoError(t, err)
+	require.NoError(t, form.Save(t.TempDir()))
+
+	t.Run("Summaries round trip", func(t *testing.T) {
+		parsed, err := ParseSummary(form.Summary())
+		require.NoError(t, err)
+		require.Equal(t, form.Fields, parsed.Fields)
+		require.Len(t, parsed.Files, 1)
+
+		file := form.Files[0]
+		file.header = nil
+		require.Equal(t, file, parsed.Files[0])
+	})
+
+	t.Run("Saved files are encoded again", func(t *testing.T) {
+		parsed, err := ParseSummary(form.Summary())
+		require.NoError(t, err)
+
+		contentType, body, err := parsed.Encode()
+		require.NoError(t, err)
+
+		again, err := Parse(contentType, body)
+		require.NoError(t, err)
+		require.Equal(t, form.Fields, again.Fields)
+		require.Equal(t, form.Files[0].SHA256, again.Files[0].SHA256)
+	})
+
+	t.Run("Unsaved files cannot be encoded", func(t *testing.T) {
+		parsed, err := ParseSummary(`file photo name="a b.png" size=5 type="" sha256=abc`)
+		require.NoError(t, err)
+		require.Equal(t, "a b.png", parsed.Files[0].Name)
+
+		_, _, err = parsed.Encode()
+		require.ErrorIs(t, err, ErrNotSaved)
+	})
+
+	t.Run("Raw bodies are not summaries", func(t *testing.T) {
+		_, err := ParseSummary(string(body))
+		require.ErrorIs(t, err, ErrNotSummary)
+	})
+}
@@ -1,41 +1,132 @@
 package internal
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/kaato137/quickrest/internal/pkg/clock"
+	"github.com/kaato137/quickrest/internal/pkg/faker"
 	"github.com/robertkrimen/otto"
 )
 
+var errHalted = errors.New("script halted")
+
 type RenderContext map[string]string
 
 type Renderer struct {
-	vm *otto.Otto
+	vm    *otto.Otto
+	rnd   *rand.Rand
+	faker *faker.Faker
+	clock *clock.Clock
 }
 
 func NewRenderer() *Renderer {
+	seed := time.Now().UnixNano()
+
 	r := &Renderer{
-		vm: otto.New(),
+		vm:    otto.New(),
+		rnd:   rand.New(rand.NewSource(seed)),
+		faker: faker.New(seed),
 	}
 
+	r.vm.SetRandomSource(r.rnd.Float64)
 	r.registerHelperFunctions()
 
 	return r
 }
 
+// WithSeed makes Math.random(), uuid() and the faker helpers produce the
+// same sequence for the same seed.
+func (r *Renderer) WithSeed(seed int64) *Renderer {
+	r.rnd.Seed(seed)
+	r.faker.Seed(seed)
+
+	return r
+}
+
+// WithClock makes now() tell the time of c instead of the real time.
+func (r *Renderer) WithClock(c *clock.Clock) *Renderer {
+	r.clock = c
+
+	return r
+}
+
 func (r *Renderer) Render(template string, renderCtx RenderContext) ([]byte, error) {
-	for k, v := range renderCtx {
-		if err := r.vm.Set(k, v); err != nil {
-			return nil, fmt.Errorf("set %q: %w", k, err)
-		}
+	val, err := r.Eval(template, renderCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	return val.Object().MarshalJSON()
+}
+
+// Eval runs template and returns its completion value as is.
+func (r *Renderer) Eval(template string, renderCtx RenderContext) (otto.Value, error) {
+	if err := r.setContext(renderCtx); err != nil {
+		return otto.UndefinedValue(), err
 	}
 
 	val, err := r.vm.Run(template)
 	if err != nil {
-		return nil, fmt.Errorf("js: %w", err)
+		return otto.UndefinedValue(), fmt.Errorf("js: %w", err)
 	}
 
-	return val.Object().MarshalJSON()
+	return val, nil
+}
+
+// RunContext executes script for its side effects only. Execution is
+// interrupted as soon as ctx is done, in which case ctx.Err() is returned.
+func (r *Renderer) RunContext(ctx context.Context, script string, renderCtx RenderContext) (err error) {
+	if err := r.setContext(renderCtx); err != nil {
+		return err
+	}
+
+	r.vm.Interrupt = make(chan func(), 1)
+	stop := context.AfterFunc(ctx, func() {
+		r.vm.Interrupt <- func() { panic(errHalted) }
+	})
+	defer stop()
+
+	defer func() {
+		if caught := recover(); caught != nil {
+			if caught != errHalted {
+				panic(caught)
+			}
+			err = ctx.Err()
+		}
+	}()
+
+	if _, err := r.vm.Run(script); err != nil {
+		return fmt.Errorf("js: %w", err)
+	}
+
+	return nil
+}
+
+// SeedFaker makes the `faker` helpers produce the same data for the same
+// seed.
+func (r *Renderer) SeedFaker(seed int64) {
+	r.faker.Seed(seed)
+}
+
+// Set exposes a Go value or function to scripts under name.
+func (r *Renderer) Set(name string, value any) error {
+	return r.vm.Set(name, value)
+}
+
+func (r *Renderer) setContext(renderCtx RenderContext) error {
+	for k, v := range renderCtx {
+		if err := r.vm.Set(k, v); err != nil {
+			return fmt.Errorf("set %q: %w", k, err)
+		}
+	}
+
+	return nil
 }
 
 func (r *Renderer) registerHelperFunctions() {
@@ -57,7 +148,12 @@ func (r *Renderer) registerHelperFunctions() {
 		args := call.ArgumentList
 
 		if len(args) == 0 {
-			val, err := r.vm.ToValue(uuid.NewString())
+			id, err := uuid.NewRandomFromReader(r.rnd)
+			if err != nil {
+				return r.vm.MakeTypeError(err.Error())
+			}
+
+			val, err := r.vm.ToValue(id.String())
 			if err != nil {
 				return r.vm.MakeTypeError(err.Error())
 			}
@@ -76,4 +172,142 @@ func (r *Renderer) registerHelperFunctions() {
 
 		return val
 	})
+
+	r.vm.Set("now", func(call otto.FunctionCall) otto.Value {
+		now := time.Now()
+		if r.clock != nil {
+			now = r.clock.Now()
+		}
+
+		val, err := r.vm.ToValue(now.Format(time.RFC3339Nano))
+		if err != nil {
+			return r.vm.MakeTypeError(err.Error())
+		}
+
+		return val
+	})
+
+	r.registerFaker()
+	r.registerPolyfills()
+}
+
+// registerPolyfills adds the ES2015 array lookups otto lacks, which make
+// querying fixtures pleasant.
+func (r *Renderer) registerPolyfills() {
+	_, _ = r.vm.Run(`
+		Object.defineProperty(Array.prototype, "findIndex", {
+			value: function(predicate, thisArg) {
+				for (var i = 0; i < this.length; i++) {
+					if (predicate.call(thisArg, this[i], i, this)) return i;
+				}
+				return -1;
+			}
+		});
+		Object.defineProperty(Array.prototype, "find", {
+			value: function(predicate, thisArg) {
+				var i = this.findIndex(predicate, thisArg);
+				return i < 0 ? undefined : this[i];
+			}
+		});
+	`)
+}
+
+func (r *Renderer) registerFaker() {
+	obj, _ := r.vm.Object(`({})`)
+
+	str := func(fn func() string) func(otto.FunctionCall) otto.Value {
+		return func(call otto.FunctionCall) otto.Value {
+			val, err := r.vm.ToValue(fn())
+			if err != nil {
+				return r.vm.MakeTypeError(err.Error())
+			}
+			return val
+		}
+	}
+
+	intArg := func(call otto.FunctionCall, i int, def int) int {
+		if !call.Argument(i).IsDefined() {
+			return def
+		}
+
+		n, err := call.Argument(i).ToInteger()
+		if err != nil {
+			return def
+		}
+
+		return int(n)
+	}
+
+	obj.Set("seed", func(call otto.FunctionCall) otto.Value {
+		arg, err := call.Argument(0).ToString()
+		if err != nil {
+			return r.vm.MakeTypeError(err.Error())
+		}
+
+		r.faker.Seed(faker.SeedString(arg))
+
+		return otto.UndefinedValue()
+	})
+
+	obj.Set("int", func(call otto.FunctionCall) otto.Value {
+		val, err := r.vm.ToValue(r.faker.Int(intArg(call, 0, 0), intArg(call, 1, 100)))
+		if err != nil {
+			return r.vm.MakeTypeError(err.Error())
+		}
+		return val
+	})
+
+	obj.Set("words", func(call otto.FunctionCall) otto.Value {
+		return str(func() string { return r.faker.Words(intArg(call, 0, 3)) })(call)
+	})
+
+	obj.Set("imageUrl", func(call otto.FunctionCall) otto.Value {
+		return str(func() string {
+			return r.faker.ImageURL(intArg(call, 0, 640), intArg(call, 1, 480))
+		})(call)
+	})
+
+	obj.Set("date", str(func() string { return r.faker.Date().Format(time.RFC3339) }))
+
+	for name, fn := range map[string]func() string{
+		"name":      r.faker.Name,
+		"firstName": r.faker.FirstName,
+		"lastName":  r.faker.LastName,
+		"email":     r.faker.Email,
+		"phone":     r.faker.Phone,
+		"address":   r.faker.Address,
+		"street":    r.faker.Street,
+		"city":      r.faker.City,
+		"country":   r.faker.Country,
+		"zip":       r.faker.Zip,
+		"company":   r.faker.Company,
+		"word":      r.faker.Word,
+		"sentence":  r.faker.Sentence,
+		"paragraph": r.faker.Paragraph,
+		"iban":      r.faker.IBAN,
+		"ipv4":      r.faker.IPv4,
+		"ipv6":      r.faker.IPv6,
+		"color":     r.faker.Color,
+		"hexColor":  r.faker.HexColor,
+		"uuid":      r.faker.UUID,
+	} {
+		obj.Set(name, str(fn))
+	}
+
+	r.vm.Set("faker", obj)
+}
+
+// encodeValue turns a script value into a message payload: strings are
+// passed through, everything else is encoded as JSON.
+func encodeValue(val otto.Value) ([]byte, error) {
+	if val.IsString() {
+		return []byte(val.String()), nil
+	}
+
+	exported, err := val.Export()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(exported)
 }
@@ -3,15 +3,20 @@ package internal
 import (
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path"
+	"sync"
+
+	"github.com/kaato137/quickrest/internal/pkg/recording"
 )
 
 type RequestRecorder struct {
-	recordPath string
-	files      map[string]*os.File
+	recordPath  string
+	uploadDir   string
+	credentials bool
+	files       map[string]*os.File
+	mutex       sync.Mutex
 }
 
 func NewRequestRecorder(recordPath string) *RequestRecorder {
@@ -21,13 +26,75 @@ func NewRequestRecorder(recordPath string) *RequestRecorder {
 	}
 }
 
-func (rec *RequestRecorder) Record(name string, r *http.Request) error {
+// WithUploadDir makes the recorder save the files of multipart requests
+// into dir. Nothing is saved when dir is empty.
+func (rec *RequestRecorder) WithUploadDir(dir string) *RequestRecorder {
+	rec.uploadDir = dir
+
+	return rec
+}
+
+// WithCredentials makes the recorder keep credential headers such as
+// Authorization and Cookie, which are redacted by default.
+func (rec *RequestRecorder) WithCredentials(keep bool) *RequestRecorder {
+	rec.credentials = keep
+
+	return rec
+}
+
+// Record appends r, and the response it got when known, to the log of
+// name. Multipart bodies are written as a summary of their fields and files
+// rather than raw, and credential headers are redacted unless kept.
+func (rec *RequestRecorder) Record(name string, r *http.Request, response *recording.Response) error {
+	entry := recording.Entry{
+		Title:    fmt.Sprintf("%s %s", r.Method, r.URL.String()),
+		Header:   r.Header,
+		Response: response,
+	}
+
+	if !rec.credentials {
+		entry.Header = recording.Redact(entry.Header)
+		if response != nil {
+			redacted := *response
+			redacted.Header = recording.Redact(response.Header)
+			entry.Response = &redacted
+		}
+	}
+
+	if form := requestUpload(r); form != nil {
+		if rec.uploadDir != "" {
+			if err := form.Save(rec.uploadDir); err != nil {
+				return fmt.Errorf("save uploads: %w", err)
+			}
+		}
+		entry.Body = []byte(form.Summary())
+	} else {
+		body, err := peekBody(r)
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+		entry.Body = body
+	}
+
+	return rec.record(name, entry)
+}
+
+// RecordMessage records a request that did not come over plain HTTP, such
+// as a gRPC call, under the given title line.
+func (rec *RequestRecorder) RecordMessage(name, title string, body []byte) error {
+	return rec.record(name, recording.Entry{Title: title, Body: body})
+}
+
+func (rec *RequestRecorder) record(name string, entry recording.Entry) error {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
 	f, err := rec.openOrCreateFile(name)
 	if err != nil {
 		return err
 	}
 
-	if err := rec.writeRequest(f, r); err != nil {
+	if err := recording.Write(f, entry); err != nil {
 		return fmt.Errorf("write req: %w", err)
 	}
 
@@ -35,6 +102,9 @@ func (rec *RequestRecorder) Record(name string, r *http.Request) error {
 }
 
 func (rec *RequestRecorder) Close() error {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
 	var compoundErr error
 	for i := range rec.files {
 		if err := rec.files[i].Close(); err != nil {
@@ -45,22 +115,6 @@ func (rec *RequestRecorder) Close() error {
 	return compoundErr
 }
 
-func (rec *RequestRecorder) writeRequest(f *os.File, r *http.Request) error {
-	if _, err := fmt.Fprintf(f, "%s %s\n", r.Method, r.URL.String()); err != nil {
-		return err
-	}
-
-	if _, err := io.Copy(f, r.Body); err != nil {
-		return err
-	}
-
-	if _, err := f.WriteString("\n\n"); err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (rec *RequestRecorder) openOrCreateFile(name string) (*os.File, error) {
 	fullPath := path.Join(rec.recordPath, name)
 
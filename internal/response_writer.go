@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// statusRecorder remembers the status code actually written, while still
+// letting SSE flush and WebSocket hijack the connection.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+	size   int
+
+	captured *bytes.Buffer
+	limit    int
+
+	// encoded is set when the body was compressed. The plain body was
+	// captured instead, and plainSize is its length.
+	encoded   bool
+	plainSize int
+}
+
+func newStatusRecorder(rw http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: rw}
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+
+	if w.captured != nil && !w.encoded && w.captured.Len() < w.limit {
+		w.captured.Write(b[:min(n, w.limit-w.captured.Len())])
+	}
+
+	return n, err
+}
+
+// Status returns the written status code, or 0 if nothing was written.
+func (w *statusRecorder) Status() int {
+	return w.status
+}
+
+// Size returns the number of body bytes written.
+func (w *statusRecorder) Size() int {
+	return w.size
+}
+
+// captureBody keeps a copy of up to limit bytes of the body. When asked
+// more than once, the largest limit wins.
+func (w *statusRecorder) captureBody(limit int) {
+	if w.captured == nil {
+		w.captured = &bytes.Buffer{}
+	}
+	w.limit = max(w.limit, limit)
+}
+
+// captureEncoded keeps plain, the body before compression, instead of the
+// compressed body about to be written, so that recordings and the dashboard
+// stay readable.
+func (w *statusRecorder) captureEncoded(plain []byte) {
+	w.encoded = true
+	w.plainSize = len(plain)
+
+	if w.captured != nil {
+		w.captured.Reset()
+		w.captured.Write(plain[:min(len(plain), w.limit)])
+	}
+}
+
+func (w *statusRecorder) capturedBody() string {
+	if w.captured == nil {
+		return ""
+	}
+
+	return w.captured.String()
+}
+
+// capturedAll reports whether the whole body was captured.
+func (w *statusRecorder) capturedAll() bool {
+	size := w.size
+	if w.encoded {
+		size = w.plainSize
+	}
+
+	return w.captured != nil && w.captured.Len() == size
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	// A hijacked connection switches protocols.
+	w.status = http.StatusSwitchingProtocols
+
+	return h.Hijack()
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// bufferedWriter holds back the body, so that it can be compressed or
+// replaced by a 304 before anything is sent.
+type bufferedWriter struct {
+	rw     http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedWriter(rw http.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{rw: rw}
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.rw.Header()
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.body.Write(b)
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kaato137/quickrest/internal/conf"
+)
+
+// probeMethods are tried to tell a 405 from a 404.
+var probeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// router serves ServeMux patterns and the routes ServeMux cannot express,
+// path_regex routes and typed wildcards. Regex routes are tried by
+// priority and win over a ServeMux pattern of lower priority. Their
+// capture groups become path values.
+type router struct {
+	mux    *http.ServeMux
+	routes map[string]conf.RouteConfig
+	regex  []regexRoute
+
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+}
+
+type regexRoute struct {
+	route   conf.RouteConfig
+	handler http.Handler
+}
+
+func (rt *router) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, pattern, _ := rt.muxRoute(r)
+
+	if rr, match := rt.matchRegex(r); rr != nil && (pattern == "" || rr.route.Priority > route.Priority) {
+		for i, name := range rr.route.PathMatcher().SubexpNames() {
+			if name != "" {
+				r.SetPathValue(name, match[i])
+			}
+		}
+
+		rr.handler.ServeHTTP(rw, r)
+		return
+	}
+
+	if pattern == "" {
+		if allowed := rt.allowedMethods(r); len(allowed) > 0 {
+			if rt.methodNotAllowed != nil {
+				rw.Header().Set("Allow", strings.Join(allowed, ", "))
+				rt.methodNotAllowed.ServeHTTP(rw, r)
+				return
+			}
+		} else if rt.notFound != nil {
+			rt.notFound.ServeHTTP(rw, r)
+			return
+		}
+	}
+
+	rt.mux.ServeHTTP(rw, r)
+}
+
+// muxRoute returns the ServeMux pattern of a request and, unless it
+// belongs to a built-in endpoint, its route.
+func (rt *router) muxRoute(r *http.Request) (conf.RouteConfig, string, bool) {
+	_, pattern := rt.mux.Handler(r)
+	route, ok := rt.routes[pattern]
+
+	return route, pattern, ok
+}
+
+// find returns the route serving a request.
+func (rt *router) find(r *http.Request) (conf.RouteConfig, bool) {
+	route, pattern, ok := rt.muxRoute(r)
+
+	if rr, _ := rt.matchRegex(r); rr != nil && (pattern == "" || rr.route.Priority > route.Priority) {
+		return rr.route, true
+	}
+
+	return route, ok
+}
+
+func (rt *router) matchRegex(r *http.Request) (*regexRoute, []string) {
+	for i := range rt.regex {
+		rr := &rt.regex[i]
+		if !methodMatches(rr.route.Method, r.Method) {
+			continue
+		}
+
+		if match := rr.route.PathMatcher().FindStringSubmatch(r.URL.Path); match != nil {
+			return rr, match
+		}
+	}
+
+	return nil, nil
+}
+
+// allowedMethods lists the methods the path of a request would be served
+// with. It is empty when no route has the path at all.
+func (rt *router) allowedMethods(r *http.Request) []string {
+	var allowed []string
+
+	probe := r.Clone(r.Context())
+	for _, method := range probeMethods {
+		probe.Method = method
+
+		if _, pattern := rt.mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+		} else if rr, _ := rt.matchRegex(probe); rr != nil {
+			allowed = append(allowed, method)
+		}
+	}
+
+	return allowed
+}
+
+// methodMatches follows ServeMux, where GET routes also answer HEAD.
+func methodMatches(method, requested string) bool {
+	return method == "" || method == requested ||
+		(method == http.MethodGet && requested == http.MethodHead)
+}
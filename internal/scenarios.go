@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/scenario"
+)
+
+// routeGroup serves the routes sharing a path, which are the responses of
+// the path. A response pinned from the dashboard answers if it is enabled;
+// otherwise the first enabled route whose scenario is in the required
+// state does, and when none is, the path is not found.
+type routeGroup struct {
+	scenarios *scenario.Store
+	enabled   func(conf.RouteConfig) bool
+	pinned    func(path string) (int, bool)
+	routes    []conf.RouteConfig
+	responses []int
+	handlers  []http.Handler
+
+	// notFound is the configured not_found response, if any.
+	notFound http.Handler
+}
+
+// add appends a route, numbered response among the routes of its path in
+// configuration order.
+func (g *routeGroup) add(route conf.RouteConfig, response int, handler http.Handler) {
+	g.routes = append(g.routes, route)
+	g.responses = append(g.responses, response)
+	g.handlers = append(g.handlers, handler)
+}
+
+func (g *routeGroup) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if response, ok := g.pinned(g.routes[0].Path); ok {
+		for i, route := range g.routes {
+			if g.responses[i] == response && g.enabled(route) {
+				g.handlers[i].ServeHTTP(rw, r)
+				return
+			}
+		}
+	}
+
+	for i, route := range g.routes {
+		if g.active(route) {
+			g.handlers[i].ServeHTTP(rw, r)
+			return
+		}
+	}
+
+	if g.notFound != nil {
+		g.notFound.ServeHTTP(rw, r)
+		return
+	}
+
+	http.NotFound(rw, r)
+}
+
+func (g *routeGroup) active(route conf.RouteConfig) bool {
+	if !g.enabled(route) {
+		return false
+	}
+
+	if route.Scenario == "" || route.RequiredState == "" {
+		return true
+	}
+
+	state, _ := g.scenarios.State(route.Scenario)
+
+	return state == route.RequiredState
+}
+
+// withScenario moves the scenario of a route to its new state once the
+// route has responded.
+func (s *Server) withScenario(route conf.RouteConfig, respond responder) responder {
+	return func(rw http.ResponseWriter, r *http.Request) error {
+		if err := respond(rw, r); err != nil {
+			return err
+		}
+
+		if s.scenarios.Transition(route.Scenario, route.RequiredState, route.NewState) {
+			s.logger.Info("Scenario state changed", "scenario", route.Scenario, "state", route.NewState)
+		}
+
+		return nil
+	}
+}
+
+func scenarioInitialStates(scenarios map[string]conf.ScenarioConfig) map[string]string {
+	initial := make(map[string]string, len(scenarios))
+	for name, sc := range scenarios {
+		initial[name] = sc.Initial
+	}
+
+	return initial
+}
+
+// ResetScenarios moves every scenario back to its initial state.
+func (s *Server) ResetScenarios() {
+	s.scenarios.ResetAll()
+}
+
+func (s *Server) handleGetScenarios(rw http.ResponseWriter, r *http.Request) {
+	writeJSON(rw, http.StatusOK, s.scenarios.States())
+}
+
+func (s *Server) handleSetScenario(rw http.ResponseWriter, r *http.Request) {
+	var req struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := s.scenarios.Set(name, req.State); err != nil {
+		writeScenarioError(rw, err)
+		return
+	}
+	s.logger.Info("Scenario state set", "scenario", name, "state", req.State)
+
+	s.handleGetScenarios(rw, r)
+}
+
+func (s *Server) handleResetScenario(rw http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.scenarios.Reset(name); err != nil {
+		writeScenarioError(rw, err)
+		return
+	}
+	s.logger.Info("Scenario reset", "scenario", name)
+
+	s.handleGetScenarios(rw, r)
+}
+
+func (s *Server) handleResetScenarios(rw http.ResponseWriter, r *http.Request) {
+	s.ResetScenarios()
+	s.logger.Info("Scenarios reset")
+
+	s.handleGetScenarios(rw, r)
+}
+
+func writeScenarioError(rw http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, scenario.ErrUnknownScenario) {
+		status = http.StatusNotFound
+	}
+
+	writeJSONError(rw, status, err)
+}
@@ -1,54 +1,200 @@
 package internal
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"math/rand"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/clock"
+	"github.com/kaato137/quickrest/internal/pkg/faker"
 	"github.com/kaato137/quickrest/internal/pkg/filewatch"
+	"github.com/kaato137/quickrest/internal/pkg/marshal"
+	"github.com/kaato137/quickrest/internal/pkg/random"
+	"github.com/kaato137/quickrest/internal/pkg/recording"
 	"github.com/kaato137/quickrest/internal/pkg/rwhandler"
+	"github.com/kaato137/quickrest/internal/pkg/scenario"
 )
 
 type Server struct {
 	cfg      *conf.Config
 	cfgMutex sync.RWMutex
 
+	// ctx ends when the server is closed, stopping background work like
+	// pending callbacks.
+	ctx    context.Context
+	cancel context.CancelFunc
+
 	reqID uint64
 
+	seed          int64
+	deterministic bool
+	random        *rand.Rand
+	clock         *clock.Clock
+
 	mux         *rwhandler.RWHandler
-	renderer    *Renderer
 	reqRecorder *RequestRecorder
+	metrics     *metrics
+	activity    *activity
+
+	// disabledRoutes holds the paths of routes switched off from the
+	// dashboard, and pinnedResponses the response chosen there for a path.
+	// Both survive config reloads.
+	disabledRoutes  sync.Map
+	pinnedResponses sync.Map
+
+	scenarios *scenario.Store
+	fixtures  fixtureSet
+	grpcState atomic.Pointer[grpcState]
+	// grpcAddr is where gRPC listens. It is not changed by reloads.
+	grpcAddr string
+	oidc     *oidcProvider
 
 	closers []func()
 
 	logger Logger
+	// combinedLog writes an access line per request, as set by log.access.
+	combinedLog atomic.Bool
+	// override is applied to every reloaded config.
+	override func(*conf.Config)
+}
+
+// NewServerFromConfig creates a server that logs as the configuration
+// says. override, when set, is applied to the configuration and to every
+// reloaded one, so that command line flags survive reloads.
+func NewServerFromConfig(cfg *conf.Config, override func(*conf.Config)) (*Server, error) {
+	if override != nil {
+		override(cfg)
+	}
+
+	logger, err := NewLogger(cfg.Log)
+	if err != nil {
+		return nil, fmt.Errorf("setup logger: %w", err)
+	}
+
+	s, err := newServer(cfg, logger, override)
+	if err != nil {
+		logger.Close()
+		return nil, err
+	}
+	s.appendCloser(logger.Close)
+
+	return s, nil
 }
 
-func NewServerFromConfig(cfg *conf.Config) (*Server, error) {
-	logger := NewLogger()
+// NewServerWithLogger creates a server that logs to the given logger. The
+// configuration is only watched for changes when it was loaded from a file.
+func NewServerWithLogger(cfg *conf.Config, logger Logger) (*Server, error) {
+	return newServer(cfg, logger, nil)
+}
 
-	s := &Server{cfg: cfg, logger: logger}
+func newServer(cfg *conf.Config, logger Logger, override func(*conf.Config)) (*Server, error) {
+	s := &Server{cfg: cfg, logger: logger, override: override, metrics: newMetrics(), activity: newActivity(), scenarios: scenario.New()}
+	s.combinedLog.Store(cfg.Log.Access == conf.AccessLogCombined)
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.appendCloser(s.cancel)
+	s.setupDeterminism()
 
 	if err := s.setupMux(); err != nil {
 		return nil, fmt.Errorf("setup mux: %w", err)
 	}
 
-	s.renderer = NewRenderer()
-	s.reqRecorder = NewRequestRecorder(cfg.RecordDir)
+	s.reqRecorder = NewRequestRecorder(cfg.RecordDir).
+		WithUploadDir(cfg.UploadDir).
+		WithCredentials(cfg.RecordCredentials)
+
+	if err := s.setupGRPC(); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("setup grpc: %w", err)
+	}
+
+	if err := s.setupAdmin(); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("setup admin: %w", err)
+	}
 
 	s.logger.Info("Listen on", "addr", cfg.Address)
 
 	return s, nil
 }
 
+// setupDeterminism seeds every source of randomness from the configured
+// seed and sets the clock up. Without a seed the server is random as usual.
+func (s *Server) setupDeterminism() {
+	s.seed = time.Now().UnixNano()
+	if s.cfg.Seed != nil {
+		s.seed = *s.cfg.Seed
+		s.deterministic = true
+		s.logger.Info("Deterministic mode", "seed", s.seed)
+	}
+	s.random = random.NewLocked(s.seed)
+
+	s.clock = clock.New()
+	if s.cfg.Clock != nil {
+		s.clock.Set(s.cfg.Clock.Time, s.cfg.Clock.Frozen)
+	}
+}
+
+// newRenderer returns a script renderer seeded for the request keys
+// identify.
+func (s *Server) newRenderer(keys ...string) *Renderer {
+	return NewRenderer().WithSeed(s.requestSeed(keys...)).WithClock(s.clock)
+}
+
+// requestSeed derives a seed from the configured one and keys in
+// deterministic mode, so that a response does not depend on the order
+// requests arrive in. Otherwise it is random.
+func (s *Server) requestSeed(keys ...string) int64 {
+	if !s.deterministic {
+		return s.random.Int63()
+	}
+
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.BigEndian, s.seed)
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+	}
+
+	return int64(h.Sum64())
+}
+
+// requestKeys identify a request to a route for requestSeed.
+func requestKeys(route conf.RouteConfig, r *http.Request) []string {
+	return []string{route.Path, r.Method, r.URL.RequestURI()}
+}
+
 func (s *Server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	s.mux.ServeHTTP(rw, r)
+	if !s.combinedLog.Load() {
+		s.mux.ServeHTTP(rw, r)
+		return
+	}
+
+	// Access lines are written here, so that requests no route answers
+	// get one too.
+	started := time.Now()
+	sr := newStatusRecorder(rw)
+	s.mux.ServeHTTP(sr, r)
+
+	status := sr.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	s.logger.Access(formatAccessLine(r, started, status, sr.Size()))
 }
 
 func (s *Server) Close() {
@@ -58,23 +204,215 @@ func (s *Server) Close() {
 }
 
 func (s *Server) setupMux() error {
-	router := s.setupRouter()
+	router, err := s.setupRouter()
+	if err != nil {
+		return fmt.Errorf("setup router: %w", err)
+	}
 	s.mux = rwhandler.New(router)
 
+	if s.cfg.Path == "" {
+		return nil
+	}
+
 	if err := s.setupConfigReload(); err != nil {
 		return fmt.Errorf("setup config reload: %w", err)
 	}
 
+	if err := s.watchFixtures(); err != nil {
+		return fmt.Errorf("setup fixture reload: %w", err)
+	}
+	s.appendCloser(func() {
+		if s.fixtures.stopWatching != nil {
+			s.fixtures.stopWatching()
+		}
+	})
+
 	return nil
 }
 
-func (s *Server) setupRouter() *http.ServeMux {
+func (s *Server) setupRouter() (http.Handler, error) {
+	fixtures, err := s.loadFixtures()
+	if err != nil {
+		return nil, err
+	}
+
 	mux := http.NewServeMux()
-	for _, route := range s.cfg.Routes {
-		mux.HandleFunc(route.Path, s.handleResponse(route))
+	rt := &router{mux: mux, routes: make(map[string]conf.RouteConfig, len(s.cfg.Routes))}
+	withCORS := false
+
+	if s.cfg.OIDC != nil {
+		if err := s.registerOIDC(rt, s.cfg.OIDC); err != nil {
+			return nil, fmt.Errorf("oidc: %w", err)
+		}
+		withCORS = s.cfg.CORS != nil
+	}
+
+	// Routes sharing the top-level auth share one authenticator, so that
+	// a JWKS is only loaded once.
+	authenticators := make(map[*conf.AuthConfig]*authenticator)
+
+	if rt.notFound, err = s.fallbackHandler(s.cfg.NotFound); err != nil {
+		return nil, fmt.Errorf("not_found: %w", err)
+	}
+	if rt.methodNotAllowed, err = s.fallbackHandler(s.cfg.MethodNotAllowed); err != nil {
+		return nil, fmt.Errorf("method_not_allowed: %w", err)
+	}
+
+	s.scenarios.Define(scenarioInitialStates(s.cfg.Scenarios))
+	groups := make(map[string]*routeGroup)
+
+	// Higher priorities come first, both among routes sharing a path and
+	// among regex routes. Equal priorities keep the configuration order.
+	order := make([]int, len(s.cfg.Routes))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(s.cfg.Routes[b].Priority, s.cfg.Routes[a].Priority)
+	})
+	responses := responseNumbers(s.cfg.Routes)
+
+	for _, i := range order {
+		route := s.cfg.Routes[i]
+		handler, err := s.routeHandler(route, authenticators)
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %w", route.Path, err)
+		}
+
+		// Routes sharing a path are told apart by scenario state, so they
+		// are registered once as a group.
+		group, ok := groups[route.Path]
+		if !ok {
+			group = &routeGroup{
+				scenarios: s.scenarios,
+				enabled:   s.routeEnabled,
+				pinned:    s.pinnedResponse,
+				notFound:  rt.notFound,
+			}
+			groups[route.Path] = group
+
+			if route.PathMatcher() != nil {
+				rt.regex = append(rt.regex, regexRoute{route: route, handler: group})
+			} else {
+				mux.Handle(route.Path, group)
+				rt.routes[route.Path] = route
+			}
+		}
+		group.add(route, responses[i], handler)
+
+		withCORS = withCORS || route.CORS != nil
 	}
 
-	return mux
+	s.fixtures.replace(fixtures)
+
+	if withCORS {
+		return &corsRouter{router: rt, logger: s.logger}, nil
+	}
+
+	return rt, nil
+}
+
+// fallbackHandler serves a not_found or method_not_allowed response. It is
+// nil when the response is not configured.
+func (s *Server) fallbackHandler(route *conf.RouteConfig) (http.Handler, error) {
+	if route == nil {
+		return nil, nil
+	}
+
+	handler, err := s.routeHandler(*route, make(map[*conf.AuthConfig]*authenticator))
+	if err != nil {
+		return nil, err
+	}
+
+	return handler, nil
+}
+
+func (s *Server) routeHandler(route conf.RouteConfig, authenticators map[*conf.AuthConfig]*authenticator) (http.HandlerFunc, error) {
+	respond, err := s.responderFor(route)
+	if err != nil {
+		return nil, err
+	}
+
+	if route.Paginate != nil {
+		respond = s.withPagination(route, respond)
+	}
+
+	if route.Scenario != "" && route.NewState != "" {
+		respond = s.withScenario(route, respond)
+	}
+
+	if len(route.Callbacks) > 0 {
+		callbacks, err := s.parseCallbacks(route)
+		if err != nil {
+			return nil, err
+		}
+
+		respond = s.withCallbacks(route, callbacks, respond)
+	}
+
+	if route.Auth != nil && route.Auth.Type != conf.AuthTypeNone {
+		auth, ok := authenticators[route.Auth]
+		if !ok {
+			if auth, err = s.newAuthenticator(route.Auth); err != nil {
+				return nil, fmt.Errorf("auth: %w", err)
+			}
+			authenticators[route.Auth] = auth
+		}
+
+		respond = auth.protect(respond)
+	}
+
+	if route.Type == conf.RouteTypeHTTP && (route.Compression != nil || (route.Conditional != nil && *route.Conditional)) {
+		respond = s.withEncoding(route, respond)
+	}
+
+	return s.handleResponse(route, respond), nil
+}
+
+// responder writes the response of a route once the common work (logging,
+// latency, headers) is done.
+type responder func(rw http.ResponseWriter, r *http.Request) error
+
+func (s *Server) responderFor(route conf.RouteConfig) (responder, error) {
+	switch route.Type {
+	case conf.RouteTypeSSE:
+		return func(rw http.ResponseWriter, r *http.Request) error {
+			if err := s.streamEvents(rw, r, route); err != nil {
+				return fmt.Errorf("stream events: %w", err)
+			}
+			return nil
+		}, nil
+	case conf.RouteTypeWebSocket:
+		return func(rw http.ResponseWriter, r *http.Request) error {
+			if err := s.serveWebSocket(rw, r, route); err != nil {
+				return fmt.Errorf("websocket session: %w", err)
+			}
+			return nil
+		}, nil
+	case conf.RouteTypeGraphQL:
+		return s.graphQLResponder(route)
+	default:
+		if len(route.Bodies) > 0 {
+			return s.negotiatedResponder(route), nil
+		}
+
+		var tmpl *template.Template
+		if route.BodyTemplate != "" {
+			var err error
+			if tmpl, err = s.parseTemplate(route.Path, route.BodyTemplate); err != nil {
+				return nil, fmt.Errorf("parse body template: %w", err)
+			}
+		}
+
+		return func(rw http.ResponseWriter, r *http.Request) error {
+			rw.WriteHeader(route.StatusCode)
+
+			if err := s.renderBody(rw, r, route, tmpl); err != nil {
+				return fmt.Errorf("render body: %w", err)
+			}
+			return nil
+		}, nil
+	}
 }
 
 func (s *Server) setupConfigReload() error {
@@ -87,13 +425,34 @@ func (s *Server) setupConfigReload() error {
 			if err := s.reloadConfigFile(); err != nil {
 				return err
 			}
-			s.mux.SetHandler(s.setupRouter())
 
+			if err := s.applyLogConfig(); err != nil {
+				return err
+			}
+
+			router, err := s.setupRouter()
+			if err != nil {
+				return err
+			}
+			s.mux.SetHandler(router)
+
+			if err := s.watchFixtures(); err != nil {
+				return err
+			}
+
+			if err := s.reloadGRPC(); err != nil {
+				return err
+			}
+
+			s.metrics.reloads.WithLabelValues("success").Inc()
+			s.activity.reloaded(nil)
 			s.logger.Info("Config reloaded successfully")
 
 			return nil
 		}).
 		OnError(func(err error) bool {
+			s.metrics.reloads.WithLabelValues("failure").Inc()
+			s.activity.reloaded(err)
 			s.logger.Error("Error on config reload. Keeping old configuration", "err", err)
 			return true
 		}).
@@ -104,14 +463,33 @@ func (s *Server) setupConfigReload() error {
 	return err
 }
 
-func (s *Server) handleResponse(route conf.RouteConfig) http.HandlerFunc {
-	return func(rw http.ResponseWriter, r *http.Request) {
+func (s *Server) handleResponse(route conf.RouteConfig, respond responder) http.HandlerFunc {
+	parseUpload := usesUpload(route)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		rw := newStatusRecorder(w)
+
 		now := time.Now()
 		reqID := s.ReqID()
-		s.logger.Info("Request started", "id", reqID, "method", r.Method, "url", r.URL.String())
+		combined := s.combinedLog.Load()
+		if !combined {
+			s.logger.Info("Request started", "id", reqID, "method", r.Method, "url", r.URL.String())
+		}
+		publish := s.captureActivity(route, reqID, rw, r)
 		defer func(now time.Time) {
 			took := time.Since(now)
-			s.logger.Info("Request ended", "id", reqID, "took", took, "code", route.StatusCode)
+
+			status := rw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			publish(status, took)
+
+			if !combined {
+				s.logger.Info("Request ended", "id", reqID, "took", took, "code", status)
+			}
+
+			s.metrics.observeRequest(route.Path, r.Method, status, took)
 		}(now)
 
 		if route.Latency > 0 || route.Jitter > 0 {
@@ -126,15 +504,38 @@ func (s *Server) handleResponse(route conf.RouteConfig) http.HandlerFunc {
 			rw.Header().Set(k, v)
 		}
 
-		rw.WriteHeader(route.StatusCode)
+		if route.CORS != nil {
+			writeCORS(rw, r, route.CORS)
+		}
+
+		if route.Record {
+			rw.captureBody(recordBodyLimit)
+		}
+
+		if parseUpload {
+			var err error
+			if r, err = withUpload(rw, r); err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					http.Error(rw, "request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				s.logger.Warn("Failed to parse multipart body", "id", reqID, "err", err)
+			}
+			if r.MultipartForm != nil {
+				defer r.MultipartForm.RemoveAll()
+			}
+		}
 
-		if err := s.renderBody(rw, r, route); err != nil {
-			s.logger.Error("Failed to render body", "err", err)
+		if err := respond(rw, r); err != nil {
+			s.metrics.renderErrors.WithLabelValues(route.Path).Inc()
+			s.logger.Error("Failed to respond", "err", err)
 			return
 		}
 
 		if route.Record {
-			if err := s.reqRecorder.Record(formatRouteFilename(route), r); err != nil {
+			if err := s.reqRecorder.Record(formatRouteFilename(route), r, recordedResponse(rw)); err != nil {
+				s.metrics.recorderErrors.Inc()
 				s.logger.Error("Failed to record request", "err", err)
 				return
 			}
@@ -142,17 +543,57 @@ func (s *Server) handleResponse(route conf.RouteConfig) http.HandlerFunc {
 	}
 }
 
-func (s *Server) renderBody(rw http.ResponseWriter, r *http.Request, route conf.RouteConfig) error {
+func (s *Server) renderBody(rw http.ResponseWriter, r *http.Request, route conf.RouteConfig, tmpl *template.Template) error {
 	var (
 		body []byte
 		err  error
 	)
 	if route.BodyJS != "" {
+		// Scripts run concurrently, and an otto VM is not safe for
+		// concurrent use, so every request gets its own renderer.
+		renderer := s.newRenderer(requestKeys(route, r)...)
 		renderCtx := prepareRenderContext(route, r)
-		body, err = s.renderer.Render(route.BodyJS, renderCtx)
+		seedFaker(renderer, route, r)
+
+		if err := renderer.Set("claims", requestClaims(r)); err != nil {
+			return fmt.Errorf("set claims: %w", err)
+		}
+
+		if err := renderer.Set("fixtures", s.fixtures.all()); err != nil {
+			return fmt.Errorf("set fixtures: %w", err)
+		}
+
+		if err := renderer.Set("form", uploadFields(r)); err != nil {
+			return fmt.Errorf("set form: %w", err)
+		}
+
+		if err := renderer.Set("files", scriptFiles(r)); err != nil {
+			return fmt.Errorf("set files: %w", err)
+		}
+
+		started := time.Now()
+		body, err = renderer.Render(route.BodyJS, renderCtx)
+		s.metrics.jsDuration.WithLabelValues(route.Path).Observe(time.Since(started).Seconds())
 		if err != nil {
 			return fmt.Errorf("render js template: %w", err)
 		}
+	} else if tmpl != nil {
+		data, err := s.newTemplateData(route, r)
+		if err != nil {
+			return fmt.Errorf("prepare template data: %w", err)
+		}
+
+		body, err = executeTemplate(tmpl, data)
+		if err != nil {
+			return fmt.Errorf("render body template: %w", err)
+		}
+	} else if format, value := route.StructuredBody(); format != "" {
+		body, err = marshal.Marshal(format, marshal.Map(value, func(text string) string {
+			return resolveWildcards(text, route.Wildcards(), r)
+		}))
+		if err != nil {
+			return fmt.Errorf("encode %s body: %w", format, err)
+		}
 	} else {
 		body = formatResponseBody(route, r)
 	}
@@ -173,18 +614,32 @@ func (s *Server) reloadConfigFile() error {
 		return fmt.Errorf("load config from file: %w", err)
 	}
 
+	if s.override != nil {
+		s.override(newCfg)
+	}
 	s.cfg = newCfg
 
 	return nil
 }
 
-func (s *Server) waitLatency(r *http.Request, route conf.RouteConfig) error {
-	select {
-	case <-time.After(calcWaitDuration(route)):
-		return nil
-	case <-r.Context().Done():
-		return r.Context().Err()
+// applyLogConfig puts the log settings of a reloaded config into effect.
+func (s *Server) applyLogConfig() error {
+	s.cfgMutex.RLock()
+	cfg := s.cfg.Log
+	s.cfgMutex.RUnlock()
+
+	if l, ok := s.logger.(interface{ Apply(conf.LogConfig) error }); ok {
+		if err := l.Apply(cfg); err != nil {
+			return fmt.Errorf("apply log config: %w", err)
+		}
 	}
+	s.combinedLog.Store(cfg.Access == conf.AccessLogCombined)
+
+	return nil
+}
+
+func (s *Server) waitLatency(r *http.Request, route conf.RouteConfig) error {
+	return sleepContext(r.Context(), calcWaitDuration(s.random, route.Latency, route.Jitter))
 }
 
 func (s *Server) ReqID() uint64 {
@@ -195,6 +650,31 @@ func (s *Server) appendCloser(fn func()) {
 	s.closers = append(s.closers, fn)
 }
 
+// recordBodyLimit caps the response bodies kept for the request log.
+// Larger responses are recorded without their response.
+const recordBodyLimit = 1 << 20
+
+// recordedResponse returns the response written so far, or nil when its
+// body was too large to be kept.
+func recordedResponse(rw *statusRecorder) *recording.Response {
+	if !rw.capturedAll() {
+		return nil
+	}
+
+	header := rw.Header().Clone()
+	if rw.encoded {
+		// The body is recorded as it was before compression.
+		header.Del("Content-Encoding")
+		header.Set("Content-Length", strconv.Itoa(rw.plainSize))
+	}
+
+	return &recording.Response{
+		Status: cmp.Or(rw.Status(), http.StatusOK),
+		Header: header,
+		Body:   []byte(rw.capturedBody()),
+	}
+}
+
 func formatRouteFilename(route conf.RouteConfig) string {
 	date := time.Now().Format("2006-01-02")
 	rt := strings.ReplaceAll(route.Path, "/", " ")
@@ -203,8 +683,11 @@ func formatRouteFilename(route conf.RouteConfig) string {
 }
 
 func formatResponseBody(rc conf.RouteConfig, r *http.Request) []byte {
-	resolvedBody := rc.Body
-	for _, c := range rc.Wildcards {
+	return []byte(resolveWildcards(rc.Body, rc.Wildcards(), r))
+}
+
+func resolveWildcards(text string, wildcards []string, r *http.Request) string {
+	for _, c := range wildcards {
 		new := r.PathValue(c)
 
 		if new == "" {
@@ -213,27 +696,79 @@ func formatResponseBody(rc conf.RouteConfig, r *http.Request) []byte {
 
 		old := fmt.Sprintf("{%s}", c)
 
-		resolvedBody = strings.ReplaceAll(resolvedBody, old, new)
+		text = strings.ReplaceAll(text, old, new)
 	}
 
-	return []byte(resolvedBody)
+	return text
 }
 
 func prepareRenderContext(rc conf.RouteConfig, r *http.Request) RenderContext {
 	ctx := make(RenderContext)
-	for _, wc := range rc.Wildcards {
+	for _, wc := range rc.Wildcards() {
 		ctx[wc] = r.PathValue(wc)
 	}
 
 	return ctx
 }
 
-func calcWaitDuration(route conf.RouteConfig) time.Duration {
-	waitDuration := route.Latency
+// peekBody reads the request body and puts it back, so that it can still
+// be read by the request recorder.
+func peekBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
 
-	if route.Jitter > 0 {
-		waitDuration += time.Duration(rand.Int63n(int64(route.Jitter*2))) - route.Jitter
+	return body, nil
+}
+
+// peekBodyPrefix reads at most limit bytes of the request body and puts
+// them back in front of the rest, which is left unread.
+func peekBodyPrefix(r *http.Request, limit int) ([]byte, error) {
+	prefix, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+
+	return prefix, nil
+}
+
+// seedFaker fixes the faker seed when the route asks for it, so that the
+// same request parameters always produce the same data.
+func seedFaker(renderer *Renderer, route conf.RouteConfig, r *http.Request) {
+	if route.FakerSeed == "" {
+		return
+	}
+
+	renderer.SeedFaker(faker.SeedString(resolveWildcards(route.FakerSeed, route.Wildcards(), r)))
+}
+
+func calcWaitDuration(rnd *rand.Rand, latency, jitter time.Duration) time.Duration {
+	waitDuration := latency
+
+	if jitter > 0 {
+		waitDuration += time.Duration(rnd.Int63n(int64(jitter*2))) - jitter
 	}
 
 	return waitDuration
 }
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
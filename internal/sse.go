@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/robertkrimen/otto"
+)
+
+var errStreamingUnsupported = errors.New("response writer does not support flushing")
+
+// minLoopInterval is the shortest pass of a looping stream, so that events
+// without delays, or a script that neither emits nor sleeps, do not spin.
+const minLoopInterval = 100 * time.Millisecond
+
+type eventWriter struct {
+	rw      http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newEventWriter(rw http.ResponseWriter) (*eventWriter, error) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		return nil, errStreamingUnsupported
+	}
+
+	return &eventWriter{rw: rw, flusher: flusher}, nil
+}
+
+func (w *eventWriter) WriteEvent(ev conf.EventConfig) error {
+	var b strings.Builder
+
+	if ev.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", ev.ID)
+	}
+
+	if ev.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", ev.Event)
+	}
+
+	if ev.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", ev.Retry.Milliseconds())
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(ev.Data, "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+
+	b.WriteString("\n")
+
+	if _, err := w.rw.Write([]byte(b.String())); err != nil {
+		return err
+	}
+
+	w.flusher.Flush()
+
+	return nil
+}
+
+func (s *Server) streamEvents(rw http.ResponseWriter, r *http.Request, route conf.RouteConfig) error {
+	ew, err := newEventWriter(rw)
+	if err != nil {
+		return err
+	}
+
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(route.StatusCode)
+	ew.flusher.Flush()
+
+	if route.EventsJS != "" {
+		return s.streamScriptedEvents(ew, r, route)
+	}
+
+	for {
+		started := time.Now()
+
+		for _, ev := range route.Events {
+			if err := sleepContext(r.Context(), ev.Delay); err != nil {
+				return err
+			}
+
+			ev.ID = resolveWildcards(ev.ID, route.Wildcards(), r)
+			ev.Event = resolveWildcards(ev.Event, route.Wildcards(), r)
+			ev.Data = resolveWildcards(ev.Data, route.Wildcards(), r)
+			if err := ew.WriteEvent(ev); err != nil {
+				return fmt.Errorf("write event: %w", err)
+			}
+		}
+
+		if !route.Loop {
+			return nil
+		}
+
+		if err := paceLoop(r, started); err != nil {
+			return err
+		}
+	}
+}
+
+// paceLoop waits until a pass of a looping stream that started at started
+// took minLoopInterval. It fails once the client is gone.
+func paceLoop(r *http.Request, started time.Time) error {
+	if err := r.Context().Err(); err != nil {
+		return err
+	}
+
+	return sleepContext(r.Context(), minLoopInterval-time.Since(started))
+}
+
+func (s *Server) streamScriptedEvents(ew *eventWriter, r *http.Request, route conf.RouteConfig) error {
+	// Like body_js, every stream gets its own VM.
+	renderer := s.newRenderer(requestKeys(route, r)...)
+	seedFaker(renderer, route, r)
+
+	var emitErr error
+	emit := func(call otto.FunctionCall) otto.Value {
+		ev, err := eventFromCall(call)
+		if err != nil {
+			return call.Otto.MakeTypeError(err.Error())
+		}
+
+		if err := ew.WriteEvent(ev); err != nil {
+			emitErr = err
+			return call.Otto.MakeCustomError("EmitError", err.Error())
+		}
+
+		return otto.UndefinedValue()
+	}
+
+	sleep := func(call otto.FunctionCall) otto.Value {
+		ms, err := call.Argument(0).ToInteger()
+		if err != nil {
+			return call.Otto.MakeTypeError("failed to cast value to integer")
+		}
+
+		_ = sleepContext(r.Context(), time.Duration(ms)*time.Millisecond)
+
+		return otto.UndefinedValue()
+	}
+
+	if err := renderer.Set("emit", emit); err != nil {
+		return fmt.Errorf("set emit: %w", err)
+	}
+
+	if err := renderer.Set("sleep", sleep); err != nil {
+		return fmt.Errorf("set sleep: %w", err)
+	}
+
+	for {
+		started := time.Now()
+
+		err := renderer.RunContext(r.Context(), route.EventsJS, prepareRenderContext(route, r))
+		if emitErr != nil {
+			return fmt.Errorf("write event: %w", emitErr)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !route.Loop {
+			return nil
+		}
+
+		if err := paceLoop(r, started); err != nil {
+			return err
+		}
+	}
+}
+
+// eventFromCall builds an event out of emit(data[, {id, event, retry}]).
+// Non-string data is encoded as JSON.
+func eventFromCall(call otto.FunctionCall) (conf.EventConfig, error) {
+	var ev conf.EventConfig
+
+	data, err := encodeValue(call.Argument(0))
+	if err != nil {
+		return ev, err
+	}
+	ev.Data = string(data)
+
+	opts := call.Argument(1)
+	if !opts.IsObject() {
+		return ev, nil
+	}
+
+	if v, _ := opts.Object().Get("id"); v.IsDefined() {
+		ev.ID = v.String()
+	}
+
+	if v, _ := opts.Object().Get("event"); v.IsDefined() {
+		ev.Event = v.String()
+	}
+
+	if v, _ := opts.Object().Get("retry"); v.IsDefined() {
+		ms, err := v.ToInteger()
+		if err != nil {
+			return ev, err
+		}
+		ev.Retry = time.Duration(ms) * time.Millisecond
+	}
+
+	return ev, nil
+}
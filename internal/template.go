@@ -0,0 +1,299 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/faker"
+	"github.com/kaato137/quickrest/internal/pkg/upload"
+)
+
+// TemplateData is the dot of body templates.
+type TemplateData struct {
+	Method  string
+	Path    string
+	Params  map[string]string
+	Query   map[string]string
+	Headers map[string]string
+	Body    string
+	JSON    any
+	Claims  map[string]any
+	// Form and Files are the fields and uploaded files of a
+	// multipart/form-data body.
+	Form  map[string]string
+	Files []upload.File
+	// Fixtures are the data files of the config, by name.
+	Fixtures map[string]any
+	// State holds the state of every scenario, by name, as it was when
+	// the request arrived.
+	State map[string]string
+	Faker *faker.Faker
+
+	// random feeds randInt and uuid, seeded like the renderers.
+	random *rand.Rand
+}
+
+func (s *Server) parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(s.templateFuncs()).Parse(text)
+}
+
+func executeTemplate(tmpl *template.Template, data TemplateData) ([]byte, error) {
+	if data.random != nil {
+		clone, err := tmpl.Clone()
+		if err != nil {
+			return nil, err
+		}
+		tmpl = clone.Funcs(randomFuncs(data.random))
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *Server) newTemplateData(route conf.RouteConfig, r *http.Request) (TemplateData, error) {
+	data := TemplateData{
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Params:   make(map[string]string),
+		Query:    make(map[string]string),
+		Headers:  make(map[string]string),
+		Claims:   requestClaims(r),
+		Form:     uploadFields(r),
+		Files:    uploadFiles(r),
+		Fixtures: s.fixtures.all(),
+		State:    s.scenarios.States(),
+	}
+
+	seed := s.requestSeed(requestKeys(route, r)...)
+	data.Faker = faker.New(seed)
+	data.random = rand.New(rand.NewSource(seed))
+
+	for _, wc := range route.Wildcards() {
+		data.Params[wc] = r.PathValue(wc)
+	}
+
+	for k := range r.URL.Query() {
+		data.Query[k] = r.URL.Query().Get(k)
+	}
+
+	for k := range r.Header {
+		data.Headers[k] = r.Header.Get(k)
+	}
+
+	if route.FakerSeed != "" {
+		data.Faker.Seed(faker.SeedString(resolveWildcards(route.FakerSeed, route.Wildcards(), r)))
+	}
+
+	body, err := peekBody(r)
+	if err != nil {
+		return data, err
+	}
+	data.Body = string(body)
+
+	var decoded any
+	if json.Unmarshal(body, &decoded) == nil {
+		data.JSON = decoded
+	}
+
+	return data, nil
+}
+
+func (s *Server) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"fromJSON": func(text string) (any, error) {
+			var v any
+			err := json.Unmarshal([]byte(text), &v)
+			return v, err
+		},
+		"add": func(a, b any) any {
+			return arith(a, b, func(x, y int64) int64 { return x + y }, func(x, y float64) float64 { return x + y })
+		},
+		"sub": func(a, b any) any {
+			return arith(a, b, func(x, y int64) int64 { return x - y }, func(x, y float64) float64 { return x - y })
+		},
+		"mul": func(a, b any) any {
+			return arith(a, b, func(x, y int64) int64 { return x * y }, func(x, y float64) float64 { return x * y })
+		},
+		"div": func(a, b any) (float64, error) {
+			if toFloat(b) == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return toFloat(a) / toFloat(b), nil
+		},
+		"mod": func(a, b any) (int, error) {
+			if toInt(b) == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return toInt(a) % toInt(b), nil
+		},
+		"int":   toInt,
+		"float": toFloat,
+		"seq":   seq,
+		"now":   s.clock.Now,
+		"date": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"unix": func(t time.Time) int64 { return t.Unix() },
+		"default": func(def, v any) any {
+			if v == nil || v == "" {
+				return def
+			}
+			return v
+		},
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"replace":  strings.ReplaceAll,
+		"split":    strings.Split,
+		"join":     strings.Join,
+		"contains": strings.Contains,
+		"where":    where,
+		"findBy": func(list any, key string, value any) any {
+			if found := where(list, key, value); len(found) > 0 {
+				return found[0]
+			}
+			return nil
+		},
+	}
+	maps.Copy(funcs, randomFuncs(s.random))
+
+	return funcs
+}
+
+// randomFuncs are the template functions drawing from rnd. Templates are
+// parsed with the ones of the server and executed with the ones of the
+// request.
+func randomFuncs(rnd *rand.Rand) template.FuncMap {
+	return template.FuncMap{
+		"randInt": func(min, max int) int {
+			if max <= min {
+				return min
+			}
+			return min + rnd.Intn(max-min+1)
+		},
+		"uuid": func(name ...string) string {
+			if len(name) == 0 {
+				id, _ := uuid.NewRandomFromReader(rand.New(rand.NewSource(rnd.Int63())))
+				return id.String()
+			}
+			return uuid.NewMD5(uuid.NameSpaceOID, []byte(name[0])).String()
+		},
+	}
+}
+
+// where keeps the objects of a fixture list whose key equals value. Values
+// are compared as text, since path parameters are strings.
+func where(list any, key string, value any) []any {
+	items, _ := list.([]any)
+
+	var found []any
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if ok && fmt.Sprint(obj[key]) == fmt.Sprint(value) {
+			found = append(found, item)
+		}
+	}
+
+	return found
+}
+
+// maxSeqLength caps seq, so that a typo in a template cannot make a
+// response run out of memory.
+const maxSeqLength = 10000
+
+// seq returns the integers [0, n) for one argument and [from, to] for two,
+// which makes ranging in templates easy.
+func seq(args ...int) ([]int, error) {
+	var from, to int
+	switch len(args) {
+	case 1:
+		from, to = 0, args[0]-1
+	case 2:
+		from, to = args[0], args[1]
+	default:
+		return nil, nil
+	}
+
+	if to-from+1 > maxSeqLength {
+		return nil, fmt.Errorf("seq of %d items exceeds the limit of %d", to-from+1, maxSeqLength)
+	}
+
+	var result []int
+	for i := from; i <= to; i++ {
+		result = append(result, i)
+	}
+
+	return result, nil
+}
+
+// arith applies ints when both operands are integers, so that counters and
+// ids stay integers, and floats otherwise.
+func arith(a, b any, ints func(x, y int64) int64, floats func(x, y float64) float64) any {
+	x, xInt := toInteger(a)
+	y, yInt := toInteger(b)
+	if xInt && yInt {
+		return ints(x, y)
+	}
+
+	return floats(toFloat(a), toFloat(b))
+}
+
+// toInteger reports whether v is an integer: a Go integer, a whole number
+// decoded from JSON or a string such as a path parameter.
+func toInteger(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) <= 1<<53 {
+			return int64(n), true
+		}
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+		return i, err == nil
+	}
+
+	return 0, false
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	case string:
+		var f float64
+		_, _ = fmt.Sscan(n, &f)
+		return f
+	default:
+		return 0
+	}
+}
+
+func toInt(v any) int {
+	return int(toFloat(v))
+}
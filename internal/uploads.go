@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kaato137/quickrest/internal/conf"
+	"github.com/kaato137/quickrest/internal/pkg/upload"
+)
+
+type uploadKey struct{}
+
+const (
+	// maxUploadSize caps a multipart body. Larger ones are answered with
+	// 413 Request Entity Too Large.
+	maxUploadSize = 32 << 20
+
+	// uploadMemory is how much of the files is held in memory. The rest
+	// is spilled to temporary files, removed once the request is served.
+	uploadMemory = 1 << 20
+)
+
+// usesUpload reports whether a route reads the multipart form, in its
+// templates, script or callbacks, or records it. The bodies of other
+// routes are left unread.
+func usesUpload(route conf.RouteConfig) bool {
+	if route.Record {
+		return true
+	}
+
+	templates := []string{route.BodyTemplate}
+	for _, body := range route.Bodies {
+		templates = append(templates, body)
+	}
+	for _, cb := range route.Callbacks {
+		templates = append(templates, cb.URL, cb.Body)
+		for _, value := range cb.Headers {
+			templates = append(templates, value)
+		}
+	}
+
+	for _, text := range templates {
+		if strings.Contains(text, ".Form") || strings.Contains(text, ".Files") {
+			return true
+		}
+	}
+
+	return strings.Contains(route.BodyJS, "form") || strings.Contains(route.BodyJS, "files")
+}
+
+// withUpload parses a multipart/form-data body once, so that templates,
+// scripts and the recorder share the result. Other requests are returned
+// as is.
+func withUpload(rw http.ResponseWriter, r *http.Request) (*http.Request, error) {
+	if !upload.IsMultipart(r.Header.Get("Content-Type")) {
+		return r, nil
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		return r, fmt.Errorf("read body: %w", err)
+	}
+
+	form, err := upload.FromMultipart(r.MultipartForm)
+	if err != nil {
+		return r, err
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), uploadKey{}, form)), nil
+}
+
+// requestUpload returns the parsed multipart form, or nil when the request
+// did not carry one.
+func requestUpload(r *http.Request) *upload.Form {
+	form, _ := r.Context().Value(uploadKey{}).(*upload.Form)
+	return form
+}
+
+// uploadFields returns the text fields of a multipart form, empty for other
+// requests.
+func uploadFields(r *http.Request) map[string]string {
+	if form := requestUpload(r); form != nil {
+		return form.Fields
+	}
+
+	return map[string]string{}
+}
+
+// uploadFiles returns the files of a multipart form, empty for other
+// requests.
+func uploadFiles(r *http.Request) []upload.File {
+	if form := requestUpload(r); form != nil && form.Files != nil {
+		return form.Files
+	}
+
+	return []upload.File{}
+}
+
+// scriptFiles turns the uploaded files into plain objects, so that scripts
+// see the same lower case keys as JSON bodies.
+func scriptFiles(r *http.Request) []map[string]any {
+	files := uploadFiles(r)
+
+	objects := make([]map[string]any, 0, len(files))
+	for _, file := range files {
+		objects = append(objects, map[string]any{
+			"field":       file.Field,
+			"name":        file.Name,
+			"size":        file.Size,
+			"contentType": file.ContentType,
+			"sha256":      file.SHA256,
+		})
+	}
+
+	return objects
+}
@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kaato137/quickrest/internal/conf"
+)
+
+// wsCloseTimeout is how long a client has to answer a close frame before
+// the connection is dropped.
+const wsCloseTimeout = time.Second
+
+var upgrader = websocket.Upgrader{
+	// Mocks are meant to be called from any local frontend.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type wsSession struct {
+	conn     *websocket.Conn
+	route    conf.RouteConfig
+	r        *http.Request
+	renderer *Renderer
+
+	// mutex serializes writes and script runs, since both the reader loop
+	// and periodic senders use the connection and the VM.
+	mutex sync.Mutex
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (s *Server) serveWebSocket(rw http.ResponseWriter, r *http.Request, route conf.RouteConfig) error {
+	respHeader := make(http.Header)
+	for k, v := range route.Headers {
+		respHeader.Set(k, v)
+	}
+
+	conn, err := upgrader.Upgrade(rw, r, respHeader)
+	if err != nil {
+		return fmt.Errorf("upgrade: %w", err)
+	}
+	defer conn.Close()
+
+	sess := &wsSession{
+		conn:     conn,
+		route:    route,
+		r:        r,
+		renderer: s.newRenderer(requestKeys(route, r)...),
+		done:     make(chan struct{}),
+	}
+	seedFaker(sess.renderer, route, r)
+
+	return sess.run()
+}
+
+func (ws *wsSession) run() error {
+	cfg := ws.route.WebSocket
+
+	for _, msg := range cfg.OnConnect {
+		if err := ws.send(msg, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range cfg.Periodic {
+		go ws.sendPeriodically(p)
+	}
+
+	if cfg.Close != nil {
+		go ws.closeAfter(*cfg.Close)
+	}
+
+	return ws.readLoop()
+}
+
+func (ws *wsSession) readLoop() error {
+	defer ws.stop()
+
+	for {
+		_, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-ws.done:
+				// The connection was closed on our side.
+				return nil
+			default:
+			}
+
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				return nil
+			}
+			return fmt.Errorf("read message: %w", err)
+		}
+
+		// Once closing, only the answer of the client is waited for.
+		select {
+		case <-ws.done:
+			continue
+		default:
+		}
+
+		reply, ok := ws.findReply(data)
+		if !ok {
+			continue
+		}
+
+		if err := ws.send(reply.WSMessageConfig, data); err != nil {
+			return err
+		}
+
+		if reply.Close != nil {
+			if err := ws.close(*reply.Close); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (ws *wsSession) findReply(data []byte) (conf.WSReplyConfig, bool) {
+	var decoded any
+	isJSON := json.Unmarshal(data, &decoded) == nil
+
+	for _, reply := range ws.route.WebSocket.Replies {
+		if reply.MatchRegexp() != nil && !reply.MatchRegexp().Match(data) {
+			continue
+		}
+
+		if len(reply.MatchJSON) > 0 && (!isJSON || !matchJSONFields(decoded, reply.MatchJSON)) {
+			continue
+		}
+
+		return reply, true
+	}
+
+	return conf.WSReplyConfig{}, false
+}
+
+func (ws *wsSession) sendPeriodically(p conf.WSPeriodicConfig) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := ws.send(p.WSMessageConfig, nil); err != nil {
+				ws.stop()
+				return
+			}
+		case <-ws.done:
+			return
+		}
+	}
+}
+
+func (ws *wsSession) closeAfter(c conf.WSCloseConfig) {
+	select {
+	case <-time.After(c.After):
+		_ = ws.close(c)
+	case <-ws.done:
+	}
+}
+
+// send renders msg and writes it to the connection. incoming holds the
+// message being replied to, if any, and is exposed to scripts as `message`.
+func (ws *wsSession) send(msg conf.WSMessageConfig, incoming []byte) error {
+	if err := sleepContext(ws.r.Context(), msg.Delay); err != nil {
+		return err
+	}
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	payload, err := ws.render(msg, incoming)
+	if err != nil {
+		return fmt.Errorf("render message: %w", err)
+	}
+
+	if err := ws.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	return nil
+}
+
+func (ws *wsSession) render(msg conf.WSMessageConfig, incoming []byte) ([]byte, error) {
+	if msg.SendJS == "" {
+		return []byte(resolveWildcards(msg.Send, ws.route.Wildcards(), ws.r)), nil
+	}
+
+	var message any = string(incoming)
+	var decoded any
+	if json.Unmarshal(incoming, &decoded) == nil {
+		message = decoded
+	}
+
+	if err := ws.renderer.Set("message", message); err != nil {
+		return nil, err
+	}
+
+	val, err := ws.renderer.Eval(msg.SendJS, prepareRenderContext(ws.route, ws.r))
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeValue(val)
+}
+
+func (ws *wsSession) close(c conf.WSCloseConfig) error {
+	ws.stop()
+
+	code := c.Code
+	if code == 0 {
+		code = websocket.CloseNormalClosure
+	}
+
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	// The read loop ends when the client answers, or when the connection
+	// is dropped because it did not.
+	time.AfterFunc(wsCloseTimeout, func() { ws.conn.Close() })
+
+	msg := websocket.FormatCloseMessage(code, c.Reason)
+	if err := ws.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsCloseTimeout)); err != nil {
+		return fmt.Errorf("write close: %w", err)
+	}
+
+	return nil
+}
+
+func (ws *wsSession) stop() {
+	ws.once.Do(func() { close(ws.done) })
+}
+
+// matchJSONFields reports whether actual holds the expected fields, at any
+// depth. Objects may have more fields than expected, while arrays and other
+// values must be equal.
+func matchJSONFields(actual any, expected map[string]any) bool {
+	// Decoding the expected fields as JSON makes YAML ints float64 too.
+	data, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+
+	var want any
+	if err := json.Unmarshal(data, &want); err != nil {
+		return false
+	}
+
+	return matchJSON(actual, want)
+}
+
+func matchJSON(actual, expected any) bool {
+	switch want := expected.(type) {
+	case map[string]any:
+		got, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for k, w := range want {
+			if g, ok := got[k]; !ok || !matchJSON(g, w) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		got, ok := actual.([]any)
+		if !ok || len(got) != len(want) {
+			return false
+		}
+
+		for i := range want {
+			if !matchJSON(got[i], want[i]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return reflect.DeepEqual(actual, expected)
+	}
+}
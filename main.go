@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/kaato137/quickrest/cmd"
 )
 
@@ -10,5 +12,7 @@ var (
 )
 
 func main() {
-	cmd.Execute(Version, Build)
+	if err := cmd.Execute(Version, Build); err != nil {
+		os.Exit(1)
+	}
 }
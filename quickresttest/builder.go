@@ -0,0 +1,237 @@
+package quickresttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kaato137/quickrest/internal/conf"
+)
+
+// RouteBuilder defines a route in code, mirroring the route options of the
+// configuration file.
+type RouteBuilder struct {
+	route Route
+}
+
+// On starts a route for a pattern such as "GET /users/{id}".
+func On(pattern string) *RouteBuilder {
+	return &RouteBuilder{route: Route{Path: pattern}}
+}
+
+// OnRegex starts a route whose path must fully match a regular expression.
+// Named groups work like wildcards. An empty method matches any method.
+func OnRegex(method, regex string) *RouteBuilder {
+	return &RouteBuilder{route: Route{Method: method, PathRegex: regex}}
+}
+
+func (b *RouteBuilder) Status(code int) *RouteBuilder {
+	b.route.StatusCode = code
+	return b
+}
+
+func (b *RouteBuilder) Header(key, value string) *RouteBuilder {
+	if b.route.Headers == nil {
+		b.route.Headers = make(map[string]string)
+	}
+	b.route.Headers[key] = value
+
+	return b
+}
+
+func (b *RouteBuilder) ContentType(contentType string) *RouteBuilder {
+	b.route.ContentType = contentType
+	return b
+}
+
+// Body sets a plain body. Wildcards of the pattern are substituted as in
+// the configuration file.
+func (b *RouteBuilder) Body(body string) *RouteBuilder {
+	b.route.Body = body
+	return b
+}
+
+// JSON encodes v as the body. It panics if v cannot be encoded, which is
+// a mistake in the test itself.
+func (b *RouteBuilder) JSON(v any) *RouteBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("quickresttest: encode body of %q: %v", b.route.Path, err))
+	}
+
+	b.route.Body = string(body)
+	b.route.ContentType = "application/json"
+
+	return b
+}
+
+// BodyFor adds a body served to clients accepting mediaType. A route with
+// such bodies negotiates among them.
+func (b *RouteBuilder) BodyFor(mediaType, body string) *RouteBuilder {
+	if b.route.Bodies == nil {
+		b.route.Bodies = make(map[string]string)
+	}
+	b.route.Bodies[mediaType] = body
+
+	return b
+}
+
+func (b *RouteBuilder) BodyJS(script string) *RouteBuilder {
+	b.route.BodyJS = script
+	return b
+}
+
+func (b *RouteBuilder) Template(text string) *RouteBuilder {
+	b.route.BodyTemplate = text
+	return b
+}
+
+func (b *RouteBuilder) Latency(latency, jitter time.Duration) *RouteBuilder {
+	b.route.Latency = latency
+	b.route.Jitter = jitter
+
+	return b
+}
+
+func (b *RouteBuilder) Auth(auth *AuthConfig) *RouteBuilder {
+	b.route.Auth = auth
+	return b
+}
+
+func (b *RouteBuilder) Compression(compression *CompressionConfig) *RouteBuilder {
+	b.route.Compression = compression
+	return b
+}
+
+// Conditional adds ETag and Last-Modified to the route and answers
+// conditional requests with 304. A zero lastModified stands for the time
+// the mock started.
+func (b *RouteBuilder) Conditional(lastModified time.Time) *RouteBuilder {
+	conditional := true
+	b.route.Conditional = &conditional
+	b.route.LastModified = lastModified
+
+	return b
+}
+
+// Paginate slices the JSON array the route responds with.
+func (b *RouteBuilder) Paginate(paginate *PaginateConfig) *RouteBuilder {
+	b.route.Paginate = paginate
+	return b
+}
+
+func (b *RouteBuilder) Callback(callback CallbackConfig) *RouteBuilder {
+	b.route.Callbacks = append(b.route.Callbacks, callback)
+	return b
+}
+
+// Scenario makes the route answer only while the scenario is in
+// requiredState, and move it to newState afterwards. Either state may be
+// empty.
+func (b *RouteBuilder) Scenario(name, requiredState, newState string) *RouteBuilder {
+	b.route.Scenario = name
+	b.route.RequiredState = requiredState
+	b.route.NewState = newState
+
+	return b
+}
+
+// Priority orders the route before routes of lower priority sharing its
+// path, and before regex routes of lower priority.
+func (b *RouteBuilder) Priority(priority int) *RouteBuilder {
+	b.route.Priority = priority
+	return b
+}
+
+// Route returns the route as it would appear in the configuration.
+func (b *RouteBuilder) Route() Route {
+	return b.route
+}
+
+// Routes builds a configuration out of routes.
+func Routes(builders ...*RouteBuilder) *Config {
+	cfg := &Config{}
+	for _, b := range builders {
+		cfg.Routes = append(cfg.Routes, b.Route())
+	}
+
+	return cfg
+}
+
+// Config is the same configuration quickrest reads from YAML files.
+type Config = conf.Config
+
+// Route is the configuration of a single route.
+type Route = conf.RouteConfig
+
+// AuthConfig is the auth block of a route.
+type AuthConfig = conf.AuthConfig
+
+// JWTConfig verifies bearer JWTs.
+type JWTConfig = conf.JWTConfig
+
+// CompressionConfig compresses the bodies of a route.
+type CompressionConfig = conf.CompressionConfig
+
+// PaginateConfig slices list responses.
+type PaginateConfig = conf.PaginateConfig
+
+// CallbackConfig is a request sent after a route responded.
+type CallbackConfig = conf.CallbackConfig
+
+// CORSConfig answers cross-origin requests.
+type CORSConfig = conf.CORSConfig
+
+// ClockConfig sets the time told by now() helpers.
+type ClockConfig = conf.ClockConfig
+
+// LogConfig controls the server logs.
+type LogConfig = conf.LogConfig
+
+// ScenarioConfig is a state machine shared by routes.
+type ScenarioConfig = conf.ScenarioConfig
+
+// EventConfig is an event of a server-sent events route.
+type EventConfig = conf.EventConfig
+
+// WebSocketConfig is the script of a WebSocket route.
+type WebSocketConfig = conf.WebSocketConfig
+
+// WSMessageConfig is a message sent over a WebSocket.
+type WSMessageConfig = conf.WSMessageConfig
+
+// WSReplyConfig answers matching WebSocket messages.
+type WSReplyConfig = conf.WSReplyConfig
+
+// WSPeriodicConfig sends a WebSocket message at an interval.
+type WSPeriodicConfig = conf.WSPeriodicConfig
+
+// WSCloseConfig closes a WebSocket connection.
+type WSCloseConfig = conf.WSCloseConfig
+
+// GraphQLConfig answers GraphQL queries from a schema.
+type GraphQLConfig = conf.GraphQLConfig
+
+// ResolverConfig overrides a field of a GraphQL type.
+type ResolverConfig = conf.ResolverConfig
+
+// OIDCConfig runs a mock OpenID Connect provider.
+type OIDCConfig = conf.OIDCConfig
+
+// OIDCClientConfig is a client of the OpenID Connect provider.
+type OIDCClientConfig = conf.OIDCClientConfig
+
+// OIDCUserConfig is a user who can log in to the OpenID Connect provider.
+type OIDCUserConfig = conf.OIDCUserConfig
+
+// GRPCConfig starts a gRPC listener.
+type GRPCConfig = conf.GRPCConfig
+
+// GRPCMethodConfig describes the answer of a gRPC method.
+type GRPCMethodConfig = conf.GRPCMethodConfig
+
+// LoadConfig loads a configuration file, so that tests can share the mock
+// definitions used when running quickrest by hand.
+func LoadConfig(path string) (*Config, error) {
+	return conf.LoadConfigFromFile(path)
+}
@@ -0,0 +1,54 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbacks(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan string, 1)
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		received <- r.Header.Get("X-Signature") + " " + string(body)
+	}))
+	defer webhook.Close()
+
+	srv := Start(t, Routes(
+		On("POST /payments/{id}").Status(http.StatusAccepted).Callback(CallbackConfig{
+			URL:           "{{ .JSON.callback_url }}",
+			Headers:       map[string]string{"X-Signature": "sig-{{ .Params.id }}"},
+			Body:          `{"payment": "{{ .Params.id }}", "status": "paid"}`,
+			Delay:         10 * time.Millisecond,
+			Retries:       2,
+			RetryInterval: 10 * time.Millisecond,
+		}),
+	))
+
+	resp, err := http.Post(srv.URL+"/payments/42", "application/json",
+		strings.NewReader(`{"callback_url": "`+webhook.URL+`/hook"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	select {
+	case got := <-received:
+		require.Equal(t, `sig-42 {"payment": "42", "status": "paid"}`, got)
+		require.Equal(t, int32(2), attempts.Load())
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback was not delivered")
+	}
+}
@@ -0,0 +1,104 @@
+package quickresttest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := Routes(
+		On("GET /users").JSON([]string{}),
+		On("DELETE /users/{id}").Status(http.StatusNoContent),
+		On("OPTIONS /custom").Status(http.StatusTeapot),
+		On("GET /custom"),
+	)
+	cfg.CORS = &CORSConfig{
+		AllowedOrigins:   []string{"http://localhost:*"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"X-Total-Count"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	cfg.Routes = append(cfg.Routes, Route{
+		Path: "GET /public",
+		CORS: &CORSConfig{AllowedOrigins: []string{"*"}},
+	})
+
+	srv := Start(t, cfg)
+
+	do := func(t *testing.T, method, path string, header http.Header) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		require.NoError(t, err)
+		req.Header = header
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp
+	}
+
+	preflight := func(origin, method string) http.Header {
+		return http.Header{
+			"Origin":                         {origin},
+			"Access-Control-Request-Method":  {method},
+			"Access-Control-Request-Headers": {"Content-Type"},
+		}
+	}
+
+	t.Run("Preflights are answered without OPTIONS routes", func(t *testing.T) {
+		resp := do(t, http.MethodOptions, "/users/1", preflight("http://localhost:3000", http.MethodDelete))
+
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, "http://localhost:3000", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "GET, HEAD, POST, PUT, PATCH, DELETE", resp.Header.Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "Content-Type, Authorization", resp.Header.Get("Access-Control-Allow-Headers"))
+		require.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "600", resp.Header.Get("Access-Control-Max-Age"))
+		require.ElementsMatch(t,
+			[]string{"Access-Control-Request-Method", "Access-Control-Request-Headers", "Origin"},
+			resp.Header.Values("Vary"))
+	})
+
+	t.Run("Configured OPTIONS routes take precedence", func(t *testing.T) {
+		resp := do(t, http.MethodOptions, "/custom", preflight("http://localhost:3000", http.MethodGet))
+		require.Equal(t, http.StatusTeapot, resp.StatusCode)
+	})
+
+	t.Run("Actual requests get the allowed origin and exposed headers", func(t *testing.T) {
+		resp := do(t, http.MethodGet, "/users", http.Header{"Origin": {"http://localhost:8080"}})
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "http://localhost:8080", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "X-Total-Count", resp.Header.Get("Access-Control-Expose-Headers"))
+		require.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, []string{"Origin"}, resp.Header.Values("Vary"))
+	})
+
+	t.Run("Other origins get no CORS headers", func(t *testing.T) {
+		resp := do(t, http.MethodOptions, "/users", preflight("https://evil.example", http.MethodGet))
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Methods"))
+
+		resp = do(t, http.MethodGet, "/users", http.Header{"Origin": {"https://evil.example"}})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, []string{"Origin"}, resp.Header.Values("Vary"))
+	})
+
+	t.Run("Routes can allow any origin", func(t *testing.T) {
+		resp := do(t, http.MethodGet, "/public", http.Header{"Origin": {"https://example.com"}})
+		require.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("Requests without an origin get no CORS headers", func(t *testing.T) {
+		resp := do(t, http.MethodGet, "/users", http.Header{})
+		require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Empty(t, resp.Header.Values("Vary"))
+	})
+}
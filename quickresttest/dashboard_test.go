@@ -0,0 +1,180 @@
+package quickresttest
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaato137/quickrest/internal/pkg/recording"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard(t *testing.T) {
+	srv := Start(t, Routes(
+		On("GET /orders/1").Body(`{"status": "pending"}`).Scenario("order", "", ""),
+		On("GET /orders/1").Body(`{"status": "paid"}`).Scenario("order", "paid", ""),
+		On("GET /orders/1").Status(http.StatusInternalServerError).Body(`{"error": "boom"}`),
+		On("POST /echo").Template(`{{len .Body}}`),
+	))
+
+	admin := httptest.NewServer(srv.mock.AdminHandler())
+	t.Cleanup(admin.Close)
+
+	type routeState struct {
+		Path      string `json:"path"`
+		Status    int    `json:"status"`
+		Enabled   bool   `json:"enabled"`
+		Response  int    `json:"response"`
+		Responses int    `json:"responses"`
+		Active    bool   `json:"active"`
+	}
+
+	do := func(t *testing.T, method, url, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(data)
+	}
+
+	put := func(t *testing.T, path, body string) (*http.Response, []routeState) {
+		resp, data := do(t, http.MethodPut, admin.URL+path, body)
+
+		var routes []routeState
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.Unmarshal([]byte(data), &routes), data)
+		}
+
+		return resp, routes
+	}
+
+	t.Run("Serves the page", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, admin.URL+"/", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+		require.Contains(t, body, "<html")
+	})
+
+	t.Run("Lists the responses of every path", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, admin.URL+"/routes", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var routes []routeState
+		require.NoError(t, json.Unmarshal([]byte(body), &routes))
+		require.Equal(t, []routeState{
+			{Path: "GET /orders/1", Status: 200, Enabled: true, Response: 0, Responses: 3},
+			{Path: "GET /orders/1", Status: 200, Enabled: true, Response: 1, Responses: 3},
+			{Path: "GET /orders/1", Status: 500, Enabled: true, Response: 2, Responses: 3},
+			{Path: "POST /echo", Status: 200, Enabled: true, Response: 0, Responses: 1},
+		}, routes)
+	})
+
+	t.Run("Pinned responses answer whatever the scenario state", func(t *testing.T) {
+		_, body := do(t, http.MethodGet, srv.URL+"/orders/1", "")
+		require.JSONEq(t, `{"status": "pending"}`, body)
+
+		resp, routes := put(t, "/routes/response", `{"path": "GET /orders/1", "response": 2}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.True(t, routes[2].Active)
+		require.False(t, routes[0].Active)
+
+		resp, body = do(t, http.MethodGet, srv.URL+"/orders/1", "")
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		require.JSONEq(t, `{"error": "boom"}`, body)
+
+		resp, routes = put(t, "/routes/response", `{"path": "GET /orders/1", "response": null}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.False(t, routes[2].Active)
+
+		_, body = do(t, http.MethodGet, srv.URL+"/orders/1", "")
+		require.JSONEq(t, `{"status": "pending"}`, body)
+	})
+
+	t.Run("Unknown routes and responses are rejected", func(t *testing.T) {
+		resp, _ := put(t, "/routes/response", `{"path": "GET /missing", "response": 0}`)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, _ = put(t, "/routes/response", `{"path": "GET /orders/1", "response": 3}`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = put(t, "/routes/enabled", `{"path": "GET /missing", "enabled": false}`)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Disabled routes can be enabled again", func(t *testing.T) {
+		resp, routes := put(t, "/routes/enabled", `{"path": "POST /echo", "enabled": false}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.False(t, routes[3].Enabled)
+
+		resp, _ = do(t, http.MethodPost, srv.URL+"/echo", "hello")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		_, routes = put(t, "/routes/enabled", `{"path": "POST /echo", "enabled": true}`)
+		require.True(t, routes[3].Enabled)
+
+		_, body := do(t, http.MethodPost, srv.URL+"/echo", "hello")
+		require.Equal(t, "5", body)
+	})
+
+	t.Run("Activity shows the start of large bodies without credentials", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, admin.URL+"/activity", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		// The handler still reads the whole body.
+		req, err = http.NewRequest(http.MethodPost, srv.URL+"/echo", strings.NewReader(strings.Repeat("x", 100<<10)))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		echo, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		echoed, err := io.ReadAll(echo.Body)
+		echo.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, "102400", string(echoed))
+
+		events := make(chan string)
+		go func() {
+			scanner := bufio.NewScanner(resp.Body)
+			scanner.Buffer(nil, 1<<20)
+			for scanner.Scan() {
+				if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+					events <- data
+					return
+				}
+			}
+		}()
+
+		select {
+		case data := <-events:
+			var ev struct {
+				Type    string `json:"type"`
+				Request struct {
+					Method  string      `json:"method"`
+					Headers http.Header `json:"headers"`
+					Body    string      `json:"body"`
+				} `json:"request"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(data), &ev))
+			require.Equal(t, "request", ev.Type)
+			require.Equal(t, http.MethodPost, ev.Request.Method)
+			require.Len(t, ev.Request.Body, 64<<10)
+			require.Equal(t, recording.Redacted, ev.Request.Headers.Get("Authorization"))
+		case <-time.After(5 * time.Second):
+			t.Fatal("no activity")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package quickresttest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterministicMode(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	newConfig := func() *Config {
+		seed := int64(42)
+		cfg := Routes(
+			On("GET /random").BodyJS(`({"uuid": uuid(), "n": Math.random(), "name": faker.name()})`),
+			On("GET /template").Template(`{"n": {{ randInt 1 1000000 }}, "uuid": "{{ uuid }}"}`),
+			On("GET /now").BodyJS(`({"now": now()})`),
+			On("GET /users/{id}").BodyJS(`({"id": id, "uuid": uuid(), "name": faker.name()})`),
+			On("GET /orders/{id}").Template(`{"n": {{ randInt 1 1000000 }}, "name": "{{ .Faker.Name }}"}`),
+		)
+		cfg.Seed = &seed
+		cfg.Clock = &ClockConfig{Time: start, Frozen: true}
+
+		return cfg
+	}
+
+	get := func(t *testing.T, url string) string {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(body)
+	}
+
+	t.Run("The same seed gives the same data", func(t *testing.T) {
+		first, second := Start(t, newConfig()), Start(t, newConfig())
+
+		for _, path := range []string{"/random", "/template", "/random"} {
+			require.Equal(t, get(t, first.URL+path), get(t, second.URL+path), path)
+		}
+	})
+
+	t.Run("Responses do not depend on the order of requests", func(t *testing.T) {
+		first, second := Start(t, newConfig()), Start(t, newConfig())
+		paths := []string{"/users/1", "/users/2", "/orders/1", "/orders/2"}
+
+		got := make(map[string]string)
+		for _, path := range paths {
+			got[path] = get(t, first.URL+path)
+		}
+
+		for i := len(paths) - 1; i >= 0; i-- {
+			require.Equal(t, got[paths[i]], get(t, second.URL+paths[i]), paths[i])
+		}
+		require.NotEqual(t, got["/users/1"], got["/users/2"])
+		require.NotEqual(t, got["/orders/1"], got["/orders/2"])
+	})
+
+	t.Run("A frozen clock only moves when advanced", func(t *testing.T) {
+		srv := Start(t, newConfig())
+
+		require.JSONEq(t, `{"now": "2024-03-01T12:00:00Z"}`, get(t, srv.URL+"/now"))
+		time.Sleep(10 * time.Millisecond)
+		require.JSONEq(t, `{"now": "2024-03-01T12:00:00Z"}`, get(t, srv.URL+"/now"))
+
+		admin := httptest.NewServer(srv.mock.AdminHandler())
+		t.Cleanup(admin.Close)
+
+		resp, err := http.Post(admin.URL+"/clock/advance", "application/json", strings.NewReader(`{"by": "36h"}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		require.JSONEq(t, `{"now": "2024-03-03T00:00:00Z"}`, get(t, srv.URL+"/now"))
+	})
+
+	t.Run("A frozen clock without a time freezes at startup", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Clock.Time = time.Time{}
+		srv := Start(t, cfg)
+
+		var body struct {
+			Now time.Time `json:"now"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(get(t, srv.URL+"/now")), &body))
+		require.WithinDuration(t, time.Now(), body.Now, time.Minute)
+	})
+}
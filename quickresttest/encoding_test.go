@@ -0,0 +1,131 @@
+package quickresttest
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoding(t *testing.T) {
+	lastModified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	srv := Start(t, Routes(
+		On("GET /compressed").Body(`{"id": 1}`).Compression(&CompressionConfig{}),
+		On("GET /large").Body(strings.Repeat(`{"id": 1}`, 100)).Compression(&CompressionConfig{}),
+		On("GET /forced").Body(`{"id": 1}`).Compression(&CompressionConfig{Force: "gzip"}),
+		On("GET /broken").Body(`{"id": 1}`).Compression(&CompressionConfig{Force: "identity", ContentEncoding: "gzip"}),
+		On("GET /cached").Body(`{"id": 1}`).Conditional(lastModified),
+	))
+
+	// The default transport would ask for gzip and decode it on its own.
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	get := func(t *testing.T, path string, header map[string]string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	readAll := func(t *testing.T, r io.Reader) string {
+		body, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("Bodies are compressed as accepted", func(t *testing.T) {
+		resp := get(t, "/compressed", map[string]string{"Accept-Encoding": "gzip"})
+		require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+		require.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+
+		gz, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, `{"id": 1}`, readAll(t, gz))
+
+		resp = get(t, "/compressed", map[string]string{"Accept-Encoding": "gzip;q=0.5, deflate"})
+		require.Equal(t, "deflate", resp.Header.Get("Content-Encoding"))
+
+		zr, err := zlib.NewReader(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, `{"id": 1}`, readAll(t, zr))
+	})
+
+	t.Run("Brotli bodies are compressed", func(t *testing.T) {
+		resp := get(t, "/large", map[string]string{"Accept-Encoding": "br, gzip"})
+		require.Equal(t, "br", resp.Header.Get("Content-Encoding"))
+
+		body := readAll(t, resp.Body)
+		require.Less(t, len(body), 100)
+		require.Equal(t, strings.Repeat(`{"id": 1}`, 100), readAll(t, brotli.NewReader(strings.NewReader(body))))
+	})
+
+	t.Run("Refusing every encoding gets 406", func(t *testing.T) {
+		resp := get(t, "/compressed", map[string]string{"Accept-Encoding": "zstd, identity;q=0"})
+		require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+		require.JSONEq(t, `{"error": "not_acceptable", "available": ["br", "gzip", "deflate"]}`, readAll(t, resp.Body))
+
+		resp = get(t, "/compressed", map[string]string{"Accept-Encoding": "gzip, identity;q=0"})
+		require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	})
+
+	t.Run("Bodies stay plain without Accept-Encoding", func(t *testing.T) {
+		resp := get(t, "/compressed", nil)
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, `{"id": 1}`, readAll(t, resp.Body))
+	})
+
+	t.Run("Encodings can be forced", func(t *testing.T) {
+		resp := get(t, "/forced", nil)
+		require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+		gz, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, `{"id": 1}`, readAll(t, gz))
+	})
+
+	t.Run("Content-Encoding can lie", func(t *testing.T) {
+		resp := get(t, "/broken", map[string]string{"Accept-Encoding": "gzip"})
+		require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+		require.Equal(t, `{"id": 1}`, readAll(t, resp.Body))
+	})
+
+	t.Run("Validators are added", func(t *testing.T) {
+		resp := get(t, "/cached", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotEmpty(t, resp.Header.Get("ETag"))
+		require.Equal(t, "Wed, 01 May 2024 12:00:00 GMT", resp.Header.Get("Last-Modified"))
+	})
+
+	t.Run("Matching ETags get 304", func(t *testing.T) {
+		etag := get(t, "/cached", nil).Header.Get("ETag")
+
+		resp := get(t, "/cached", map[string]string{"If-None-Match": `"other", ` + etag})
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+		require.Empty(t, readAll(t, resp.Body))
+
+		resp = get(t, "/cached", map[string]string{"If-None-Match": `"other"`})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("If-Modified-Since is honoured", func(t *testing.T) {
+		resp := get(t, "/cached", map[string]string{"If-Modified-Since": "Wed, 01 May 2024 12:00:00 GMT"})
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+		resp = get(t, "/cached", map[string]string{"If-Modified-Since": "Tue, 30 Apr 2024 12:00:00 GMT"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
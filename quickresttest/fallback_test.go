@@ -0,0 +1,96 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallback(t *testing.T) {
+	cfg := Routes(
+		On("GET /users/{id}").Body(`{"id": "{id}"}`),
+		On("GET /users/{id}").Body(`{"id": "{id}", "primary": true}`).Priority(10),
+		OnRegex("", `/api/.*`).Status(http.StatusBadGateway).Body(`{"error": "upstream"}`).Priority(-1),
+		On("GET /api/health").Body(`ok`),
+		On("GET /orders/1").Body(`{"status": "paid"}`).Scenario("order", "paid", ""),
+		On("GET /legacy").Body(`legacy`),
+	)
+	cfg.NotFound = &Route{
+		BodyTemplate: `{"error": "not_found", "path": "{{.Path}}"}`,
+	}
+	cfg.MethodNotAllowed = &Route{
+		Body: `{"error": "method_not_allowed"}`,
+	}
+
+	srv := Start(t, cfg)
+
+	do := func(t *testing.T, method, path string) (*http.Response, string) {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(body)
+	}
+
+	t.Run("Higher priorities answer first", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, "/users/1")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.JSONEq(t, `{"id": "1", "primary": true}`, body)
+	})
+
+	t.Run("Low priority catch-alls leave other routes alone", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, "/api/health")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "ok", body)
+
+		resp, body = do(t, http.MethodGet, "/api/orders")
+		require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		require.JSONEq(t, `{"error": "upstream"}`, body)
+	})
+
+	t.Run("Unknown paths get the not_found response", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, "/missing")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		require.JSONEq(t, `{"error": "not_found", "path": "/missing"}`, body)
+	})
+
+	t.Run("Routes out of scenario state get the not_found response", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, "/orders/1")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.JSONEq(t, `{"error": "not_found", "path": "/orders/1"}`, body)
+	})
+
+	t.Run("Disabled routes get the not_found response", func(t *testing.T) {
+		admin := httptest.NewServer(srv.mock.AdminHandler())
+		t.Cleanup(admin.Close)
+
+		req, err := http.NewRequest(http.MethodPut, admin.URL+"/routes/enabled", strings.NewReader(`{"path": "GET /legacy", "enabled": false}`))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, body := do(t, http.MethodGet, "/legacy")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.JSONEq(t, `{"error": "not_found", "path": "/legacy"}`, body)
+	})
+
+	t.Run("Unknown methods get the method_not_allowed response", func(t *testing.T) {
+		resp, body := do(t, http.MethodDelete, "/users/1")
+		require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		require.Equal(t, "GET, HEAD", resp.Header.Get("Allow"))
+		require.JSONEq(t, `{"error": "method_not_allowed"}`, body)
+	})
+}
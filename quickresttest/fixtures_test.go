@@ -0,0 +1,67 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaato137/quickrest/internal/pkg/testfile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	dir := t.TempDir()
+
+	testfile.Write(t, dir, "users.csv", "id,name\n1,Ann\n2,Bob\n")
+	testfile.Write(t, dir, "products.json", `[{"sku": "pen", "price": 1.5}]`)
+	testfile.Write(t, dir, "quickrest.yml", `
+reload_interval: 20ms
+fixtures:
+  users: users.csv
+  products: products.json
+routes:
+- path: GET /users/{id}
+  body_js: |
+    fixtures.users.find(function(u) { return u.id == id; }) || {}
+- path: GET /products
+  body_template: '{{ range .Fixtures.products }}{{ .sku }}={{ .price }} {{ end }}'
+- path: GET /names/{id}
+  body_template: '{{ with findBy .Fixtures.users "id" .Params.id }}{{ .name }}{{ end }}'
+`)
+
+	cfg, err := LoadConfig(filepath.Join(dir, "quickrest.yml"))
+	require.NoError(t, err)
+
+	srv := Start(t, cfg)
+
+	get := func(t *testing.T, path string) string {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(body)
+	}
+
+	t.Run("Scripts query fixtures", func(t *testing.T) {
+		require.JSONEq(t, `{"id": 2, "name": "Bob"}`, get(t, "/users/2"))
+	})
+
+	t.Run("Templates range over fixtures", func(t *testing.T) {
+		require.Equal(t, "pen=1.5 ", get(t, "/products"))
+		require.Equal(t, "Ann", get(t, "/names/1"))
+	})
+
+	t.Run("Changed files are reloaded", func(t *testing.T) {
+		testfile.Write(t, dir, "users.csv", "id,name\n1,Ann\n2,Bea\n")
+
+		require.Eventually(t, func() bool {
+			return strings.Contains(get(t, "/users/2"), "Bea")
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+}
@@ -0,0 +1,84 @@
+package quickresttest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kaato137/quickrest/internal/pkg/testfile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQL(t *testing.T) {
+	schema := testfile.Write(t, t.TempDir(), "schema.graphql", `
+type Query {
+	user(id: ID!): User
+	me: User!
+}
+
+type User {
+	id: ID!
+	name: String!
+}
+`)
+
+	srv := Start(t, &Config{GraphQL: &GraphQLConfig{
+		Schema: schema,
+		Resolvers: map[string]ResolverConfig{
+			"Query.user": {JS: `args.id === "0" ? null : {"id": args.id, "name": "User " + args.id}`},
+			"Query.me":   {Value: map[string]any{"id": "1", "name": nil}},
+		},
+	}})
+
+	query := func(t *testing.T, body string) (int, string) {
+		resp, err := http.Post(srv.URL+"/graphql", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(data)
+	}
+
+	t.Run("Resolvers answer queries", func(t *testing.T) {
+		status, body := query(t, `{"query": "{ user(id: \"7\") { name } }"}`)
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `{"data": {"user": {"name": "User 7"}}}`, body)
+	})
+
+	t.Run("Resolvers can return null", func(t *testing.T) {
+		_, body := query(t, `{"query": "{ user(id: \"0\") { name } }"}`)
+		require.JSONEq(t, `{"data": {"user": null}}`, body)
+	})
+
+	t.Run("Null non-null fields are errors", func(t *testing.T) {
+		_, body := query(t, `{"query": "{ me { id name } }"}`)
+
+		var resp struct {
+			Data   json.RawMessage  `json:"data"`
+			Errors []map[string]any `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		require.JSONEq(t, `null`, string(resp.Data))
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, []any{"me", "name"}, resp.Errors[0]["path"])
+	})
+
+	t.Run("Malformed requests get GraphQL errors", func(t *testing.T) {
+		status, body := query(t, `{"query": `)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		var resp struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		require.Len(t, resp.Errors, 1)
+		require.Contains(t, resp.Errors[0].Message, "decode request")
+	})
+}
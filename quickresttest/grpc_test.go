@@ -0,0 +1,122 @@
+package quickresttest
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaato137/quickrest/internal/pkg/protoload"
+	"github.com/kaato137/quickrest/internal/pkg/testfile"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestGRPC(t *testing.T) {
+	dir := t.TempDir()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	// The layout of the README example.
+	testfile.Write(t, dir, "protos/greeter.proto", `syntax = "proto3";
+package helloworld;
+message HelloRequest { string name = 1; }
+message HelloReply { string message = 1; }
+service Greeter {
+  rpc SayHello (HelloRequest) returns (HelloReply);
+  rpc Watch (HelloRequest) returns (stream HelloReply);
+  rpc Forget (HelloRequest) returns (HelloReply);
+}
+`)
+	testfile.Write(t, dir, "quickrest.yml", `
+grpc:
+  addr: `+addr+`
+  protos: [protos/greeter.proto]
+  import_paths: [protos]
+  methods:
+  - method: helloworld.Greeter/SayHello
+    response: {message: "Hello {name}"}
+    headers: {x-mock: "yes"}
+  - method: helloworld.Greeter/Watch
+    stream:
+    - {message: "one"}
+    - {message: "two"}
+    response_js: '({message: item.message + " for " + request.name})'
+  - method: helloworld.Greeter/Forget
+    code: NOT_FOUND
+`)
+
+	cfg, err := LoadConfig(filepath.Join(dir, "quickrest.yml"))
+	require.NoError(t, err)
+	srv := Start(t, cfg)
+
+	registry, err := protoload.FromProtoFiles(context.Background(), nil, []string{filepath.Join(dir, "protos/greeter.proto")})
+	require.NoError(t, err)
+	method := protoload.Methods(registry)["/helloworld.Greeter/SayHello"]
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	t.Run("Unary calls are answered", func(t *testing.T) {
+		req := dynamicpb.NewMessage(method.Input())
+		req.Set(method.Input().Fields().ByName("name"), protoreflect.ValueOfString("Ann"))
+		reply := dynamicpb.NewMessage(method.Output())
+
+		var header metadata.MD
+		err := conn.Invoke(context.Background(), "/helloworld.Greeter/SayHello", req, reply, grpc.Header(&header))
+		require.NoError(t, err)
+
+		require.Equal(t, "Hello Ann", reply.Get(method.Output().Fields().ByName("message")).String())
+		require.Equal(t, []string{"yes"}, header.Get("x-mock"))
+	})
+
+	t.Run("Scripts see the streamed items", func(t *testing.T) {
+		req := dynamicpb.NewMessage(method.Input())
+		req.Set(method.Input().Fields().ByName("name"), protoreflect.ValueOfString("Ann"))
+
+		stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, "/helloworld.Greeter/Watch")
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(req))
+		require.NoError(t, stream.CloseSend())
+
+		var messages []string
+		for {
+			reply := dynamicpb.NewMessage(method.Output())
+			if err := stream.RecvMsg(reply); err != nil {
+				require.ErrorIs(t, err, io.EOF)
+				break
+			}
+			messages = append(messages, reply.Get(method.Output().Fields().ByName("message")).String())
+		}
+		require.Equal(t, []string{"one for Ann", "two for Ann"}, messages)
+	})
+
+	t.Run("Calls are counted with their actual code", func(t *testing.T) {
+		err := conn.Invoke(context.Background(), "/helloworld.Greeter/Forget", dynamicpb.NewMessage(method.Input()), dynamicpb.NewMessage(method.Output()))
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		rec := httptest.NewRecorder()
+		srv.mock.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Contains(t, rec.Body.String(), `quickrest_grpc_calls_total{code="OK",method="/helloworld.Greeter/SayHello"} 1`)
+		require.Contains(t, rec.Body.String(), `quickrest_grpc_calls_total{code="NotFound",method="/helloworld.Greeter/Forget"} 1`)
+	})
+
+	t.Run("Unknown methods fail", func(t *testing.T) {
+		err := conn.Invoke(context.Background(), "/helloworld.Greeter/Nope", dynamicpb.NewMessage(method.Input()), dynamicpb.NewMessage(method.Output()))
+		require.Error(t, err)
+		require.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
@@ -0,0 +1,118 @@
+package quickresttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaato137/quickrest/internal"
+	"github.com/kaato137/quickrest/internal/pkg/testfile"
+	"github.com/stretchr/testify/require"
+)
+
+var accessLine = `^127\.0\.0\.1 - bob \[[^]]+\] "GET %s HTTP/1\.1" %d (-|\d+) "-" "tests"$`
+
+func TestLogging(t *testing.T) {
+	get := func(t *testing.T, url string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		req.Header.Set("User-Agent", "tests")
+		req.SetBasicAuth("bob", "secret")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	readLines := func(t *testing.T, path string) []string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	// serve answers requests to paths with a server logging to a file as
+	// the config says, and returns the lines of the log.
+	serve := func(t *testing.T, log LogConfig, paths ...string) []string {
+		log.File = filepath.Join(t.TempDir(), "quickrest.log")
+		logger, err := internal.NewLogger(log)
+		require.NoError(t, err)
+		defer logger.Close()
+
+		cfg := Routes(On("GET /users/{id}").Status(http.StatusCreated))
+		cfg.Log = log
+
+		srv, err := newServer(cfg, logger)
+		require.NoError(t, err)
+		defer srv.Close()
+
+		for _, path := range paths {
+			get(t, srv.URL+path)
+		}
+
+		return readLines(t, log.File)
+	}
+
+	t.Run("Writes combined access lines as they are", func(t *testing.T) {
+		lines := serve(t, LogConfig{Format: "json", Access: "combined"}, "/users/1?full=true", "/missing")
+
+		var access []string
+		for _, line := range lines {
+			if strings.HasPrefix(line, "127.0.0.1 ") {
+				access = append(access, line)
+				continue
+			}
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+			require.NotEqual(t, "Request started", entry["msg"])
+		}
+
+		require.Len(t, access, 2)
+		require.Regexp(t, fmt.Sprintf(accessLine, `/users/1\?full=true`, http.StatusCreated), access[0])
+		require.Regexp(t, fmt.Sprintf(accessLine, "/missing", http.StatusNotFound), access[1])
+	})
+
+	t.Run("Drops lines below the level", func(t *testing.T) {
+		lines := serve(t, LogConfig{Format: "json", Level: "warn"}, "/users/1")
+		require.Equal(t, []string{""}, lines)
+
+		lines = serve(t, LogConfig{Level: "warn", Access: "combined"}, "/users/1")
+		require.Len(t, lines, 1)
+		require.Regexp(t, fmt.Sprintf(accessLine, "/users/1", http.StatusCreated), lines[0])
+	})
+
+	t.Run("Reloads apply the log settings", func(t *testing.T) {
+		dir := t.TempDir()
+		logFile := filepath.Join(dir, "quickrest.log")
+
+		routes := "reload_interval: 10ms\nroutes:\n  - path: GET /users/{id}\n"
+		path := testfile.Write(t, dir, "quickrest.yml", routes)
+
+		cfg, err := LoadConfig(path)
+		require.NoError(t, err)
+		srv := Start(t, cfg)
+
+		logged := routes + fmt.Sprintf("log:\n  file: %s\n  access: combined\n", logFile)
+		testfile.Write(t, dir, "quickrest.yml", logged)
+
+		require.Eventually(t, func() bool {
+			get(t, srv.URL+"/users/1")
+			data, err := os.ReadFile(logFile)
+			return err == nil && strings.Contains(string(data), `"GET /users/1 HTTP/1.1" 200`)
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("Rejects unknown formats", func(t *testing.T) {
+		_, err := internal.NewLogger(LogConfig{Format: "xml"})
+		require.Error(t, err)
+
+		_, err = internal.NewLogger(LogConfig{Access: "common"})
+		require.Error(t, err)
+	})
+}
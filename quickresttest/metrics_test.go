@@ -0,0 +1,41 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	srv := Start(t, Routes(
+		On("GET /users/{id}").Status(http.StatusCreated),
+		On("GET /broken").BodyJS(`throw new Error("boom")`),
+	))
+
+	admin := httptest.NewServer(srv.mock.AdminHandler())
+	t.Cleanup(admin.Close)
+
+	for _, path := range []string{"/users/1", "/users/2", "/broken"} {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(admin.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	metrics := string(body)
+	require.Contains(t, metrics, `quickrest_http_requests_total{code="201",method="GET",route="GET /users/{id}"} 2`)
+	require.Contains(t, metrics, `quickrest_http_request_duration_seconds_count{code="201",method="GET",route="GET /users/{id}"} 2`)
+	require.Contains(t, metrics, `quickrest_render_errors_total{route="GET /broken"} 1`)
+	require.Contains(t, metrics, `quickrest_js_execution_seconds_count{route="GET /broken"} 1`)
+	require.Contains(t, metrics, "go_goroutines")
+}
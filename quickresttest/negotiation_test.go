@@ -0,0 +1,57 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiation(t *testing.T) {
+	srv := Start(t, Routes(
+		On("GET /exports/{id}").
+			BodyFor("application/json", `{"id": "{id}"}`).
+			BodyFor("application/xml", `<export id="{id}"/>`).
+			BodyFor("text/csv", "id\n{id}\n"),
+	))
+
+	get := func(t *testing.T, accept string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/exports/7", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(body)
+	}
+
+	t.Run("JSON is the default", func(t *testing.T) {
+		resp, body := get(t, "")
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		require.Equal(t, `{"id": "7"}`, body)
+	})
+
+	t.Run("The preferred media type is served", func(t *testing.T) {
+		resp, body := get(t, "application/json;q=0.5, text/csv")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+		require.Equal(t, "Accept", resp.Header.Get("Vary"))
+		require.Equal(t, "id\n7\n", body)
+
+		resp, body = get(t, "application/*;q=0.9, application/json;q=0.1")
+		require.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+		require.Equal(t, `<export id="7"/>`, body)
+	})
+
+	t.Run("Unacceptable requests get 406", func(t *testing.T) {
+		resp, body := get(t, "image/png")
+		require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+		require.JSONEq(t, `{"error": "not_acceptable", "available": ["application/json", "application/xml", "text/csv"]}`, body)
+	})
+}
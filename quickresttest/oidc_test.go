@@ -0,0 +1,151 @@
+package quickresttest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDC(t *testing.T) {
+	cfg := Routes(
+		On("GET /me").
+			Auth(&AuthConfig{Type: "bearer", JWT: &JWTConfig{JWKS: "oidc", Scopes: []string{"orders"}}}).
+			Template(`{{ .Claims.email }}`),
+	)
+	cfg.OIDC = &OIDCConfig{
+		Clients: []OIDCClientConfig{{ID: "web", RedirectURIs: []string{"http://app.test/callback"}}},
+		Users: []OIDCUserConfig{
+			{Username: "alice", Claims: map[string]any{"email": "alice@example.com"}},
+			{Username: "bob", Claims: map[string]any{"email": "bob@example.com"}},
+		},
+	}
+	cfg.CORS = &CORSConfig{AllowedOrigins: []string{"http://app.test"}}
+
+	srv := Start(t, cfg)
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	token := func(t *testing.T, form url.Values) map[string]any {
+		resp, err := client.PostForm(srv.URL+"/oidc/token", form)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var tokens map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&tokens))
+
+		return tokens
+	}
+
+	verifier := "a-long-enough-code-verifier-for-the-test"
+	sum := sha256.Sum256([]byte(verifier))
+
+	var tokens map[string]any
+
+	t.Run("Authorize redirects with a code", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/oidc/authorize?" + url.Values{
+			"response_type":         {"code"},
+			"client_id":             {"web"},
+			"redirect_uri":          {"http://app.test/callback"},
+			"scope":                 {"openid orders"},
+			"state":                 {"xyz"},
+			"login_hint":            {"bob"},
+			"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+			"code_challenge_method": {"S256"},
+		}.Encode())
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+
+		location, err := url.Parse(resp.Header.Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "xyz", location.Query().Get("state"))
+
+		tokens = token(t, url.Values{
+			"grant_type":    {"authorization_code"},
+			"client_id":     {"web"},
+			"code":          {location.Query().Get("code")},
+			"redirect_uri":  {"http://app.test/callback"},
+			"code_verifier": {verifier},
+		})
+		require.NotEmpty(t, tokens["id_token"])
+		require.NotEmpty(t, tokens["refresh_token"])
+	})
+
+	t.Run("Access tokens are accepted by protected routes", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/me", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+tokens["access_token"].(string))
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "bob@example.com", string(body))
+	})
+
+	t.Run("Refresh tokens are rotated", func(t *testing.T) {
+		form := url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {"web"},
+			"refresh_token": {tokens["refresh_token"].(string)},
+		}
+		refreshed := token(t, form)
+		require.NotEqual(t, tokens["refresh_token"], refreshed["refresh_token"])
+
+		resp, err := client.PostForm(srv.URL+"/oidc/token", form)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Browsers may call the token endpoint", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodOptions, srv.URL+"/oidc/token", nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", "http://app.test")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, "http://app.test", resp.Header.Get("Access-Control-Allow-Origin"))
+		require.Contains(t, resp.Header.Get("Access-Control-Allow-Methods"), http.MethodPost)
+		require.Equal(t, "Content-Type", resp.Header.Get("Access-Control-Allow-Headers"))
+
+		req, err = http.NewRequest(http.MethodPost, srv.URL+"/oidc/token", strings.NewReader(url.Values{
+			"grant_type": {"client_credentials"},
+			"client_id":  {"web"},
+		}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Origin", "http://app.test")
+
+		resp, err = client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "http://app.test", resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Unknown clients are rejected", func(t *testing.T) {
+		resp, err := client.PostForm(srv.URL+"/oidc/token", url.Values{
+			"grant_type": {"client_credentials"},
+			"client_id":  {"mobile"},
+		})
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,97 @@
+package quickresttest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	items := make([]int, 25)
+	for i := range items {
+		items[i] = i + 1
+	}
+
+	srv := Start(t, Routes(
+		On("GET /offset").JSON(items).Paginate(&PaginateConfig{}),
+		On("GET /pages").JSON(items).Paginate(&PaginateConfig{
+			Style:    "page",
+			Params:   map[string]string{"size": "per_page"},
+			Envelope: map[string]string{"items": "data", "total": "total", "page": "page"},
+		}),
+		On("GET /feed").
+			BodyJS(`(function() { var feed = []; for (var i = 1; i <= 25; i++) feed.push({id: i}); return feed; })()`).
+			Paginate(&PaginateConfig{Style: "cursor", DefaultLimit: 20, Envelope: map[string]string{"items": "items", "next_cursor": "next_cursor"}}),
+	))
+
+	get := func(t *testing.T, path string, v any) *http.Response {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		if v != nil {
+			require.NoError(t, json.Unmarshal(body, v), string(body))
+		}
+
+		return resp
+	}
+
+	t.Run("Offset and limit", func(t *testing.T) {
+		var page []int
+		resp := get(t, "/offset?offset=20&limit=3", &page)
+
+		require.Equal(t, []int{21, 22, 23}, page)
+		require.Equal(t, "25", resp.Header.Get("X-Total-Count"))
+		require.Equal(t,
+			`</offset?limit=3&offset=0>; rel="first", </offset?limit=3&offset=17>; rel="prev", </offset?limit=3&offset=23>; rel="next", </offset?limit=3&offset=24>; rel="last"`,
+			resp.Header.Get("Link"))
+	})
+
+	t.Run("Pages in an envelope", func(t *testing.T) {
+		var page struct {
+			Data  []int `json:"data"`
+			Total int   `json:"total"`
+			Page  int   `json:"page"`
+		}
+		get(t, "/pages?page=3&per_page=10", &page)
+
+		require.Equal(t, []int{21, 22, 23, 24, 25}, page.Data)
+		require.Equal(t, 25, page.Total)
+		require.Equal(t, 3, page.Page)
+	})
+
+	t.Run("Cursors over a script result", func(t *testing.T) {
+		var page struct {
+			Items      []map[string]int `json:"items"`
+			NextCursor *string          `json:"next_cursor"`
+		}
+		get(t, "/feed", &page)
+		require.Len(t, page.Items, 20)
+		require.NotNil(t, page.NextCursor)
+
+		get(t, "/feed?cursor="+*page.NextCursor, &page)
+		require.Len(t, page.Items, 5)
+		require.Equal(t, 21, page.Items[0]["id"])
+		require.Nil(t, page.NextCursor)
+	})
+
+	t.Run("Huge pages are empty", func(t *testing.T) {
+		var page struct {
+			Data []int `json:"data"`
+		}
+		resp := get(t, "/pages?page=1000000000000000000&per_page=10", &page)
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, page.Data)
+	})
+
+	t.Run("Invalid parameters get 400", func(t *testing.T) {
+		resp := get(t, "/offset?limit=abc", nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
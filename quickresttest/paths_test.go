@@ -0,0 +1,71 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaths(t *testing.T) {
+	srv := Start(t, Routes(
+		On("GET /users/me").Body(`{"id": "me"}`),
+		On("GET /users/{id:int}").Body(`{"id": {id}}`),
+		On("GET /posts/{slug:[a-z-]+}").BodyJS(`({slug: slug.toUpperCase()})`),
+		OnRegex("GET", `/legacy/(?P<page>[a-z]+)\.php`).Body(`page {page}`),
+	))
+
+	get := func(t *testing.T, path string) (int, string) {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("Plain patterns take precedence", func(t *testing.T) {
+		status, body := get(t, "/users/me")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, `{"id": "me"}`, body)
+	})
+
+	t.Run("Typed wildcards are substituted", func(t *testing.T) {
+		status, body := get(t, "/users/42")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, `{"id": 42}`, body)
+	})
+
+	t.Run("Typed wildcards reject other values", func(t *testing.T) {
+		status, _ := get(t, "/users/abc")
+		require.Equal(t, http.StatusNotFound, status)
+
+		status, _ = get(t, "/posts/Hello")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Captured values reach scripts", func(t *testing.T) {
+		status, body := get(t, "/posts/hello-world")
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `{"slug": "HELLO-WORLD"}`, body)
+	})
+
+	t.Run("Regex routes match the whole path", func(t *testing.T) {
+		status, body := get(t, "/legacy/index.php")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "page index", body)
+
+		status, _ = get(t, "/legacy/index.php/more")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Regex routes check the method", func(t *testing.T) {
+		resp, err := http.Post(srv.URL+"/legacy/index.php", "text/plain", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,110 @@
+package quickresttest
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kaato137/quickrest/internal/pkg/recording"
+	"github.com/kaato137/quickrest/internal/pkg/replay"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecording(t *testing.T) {
+	record := func(t *testing.T, keepCredentials bool) recording.Entry {
+		route := On("POST /login").Header("Set-Cookie", "session=abc").Body(`{"ok": true}`).Route()
+		route.Record = true
+
+		cfg := &Config{Routes: []Route{route}}
+		cfg.RecordDir = t.TempDir()
+		cfg.RecordCredentials = keepCredentials
+		srv := Start(t, cfg)
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/login", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Cookie", "tracking=1")
+		req.Header.Set("X-Request-Id", "7")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		logs, err := filepath.Glob(filepath.Join(cfg.RecordDir, "*.log"))
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+
+		f, err := os.Open(logs[0])
+		require.NoError(t, err)
+		defer f.Close()
+
+		entries, err := recording.Read(f)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.NotNil(t, entries[0].Response)
+
+		return entries[0]
+	}
+
+	t.Run("Credentials are redacted by default", func(t *testing.T) {
+		entry := record(t, false)
+
+		require.Equal(t, recording.Redacted, entry.Header.Get("Authorization"))
+		require.Equal(t, recording.Redacted, entry.Header.Get("Cookie"))
+		require.Equal(t, "7", entry.Header.Get("X-Request-Id"))
+		require.Equal(t, recording.Redacted, entry.Response.Header.Get("Set-Cookie"))
+		require.JSONEq(t, `{"ok": true}`, string(entry.Response.Body))
+	})
+
+	t.Run("Credentials can be kept", func(t *testing.T) {
+		entry := record(t, true)
+
+		require.Equal(t, "Bearer secret", entry.Header.Get("Authorization"))
+		require.Equal(t, "tracking=1", entry.Header.Get("Cookie"))
+		require.Equal(t, "session=abc", entry.Response.Header.Get("Set-Cookie"))
+	})
+}
+
+func TestRecordingCompressed(t *testing.T) {
+	body := `[` + strings.Repeat(`{"id": 1},`, 200) + `{"id": 2}]`
+	route := On("GET /items").Body(body).Compression(&CompressionConfig{}).Route()
+	route.Record = true
+
+	cfg := &Config{Routes: []Route{route}}
+	cfg.RecordDir = t.TempDir()
+	srv := Start(t, cfg)
+
+	resp, err := http.Get(srv.URL + "/items")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.True(t, resp.Uncompressed)
+
+	logs, err := filepath.Glob(filepath.Join(cfg.RecordDir, "*.log"))
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	f, err := os.Open(logs[0])
+	require.NoError(t, err)
+	defer f.Close()
+
+	entries, err := recording.Read(f)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	t.Run("Bodies are recorded before compression", func(t *testing.T) {
+		require.JSONEq(t, body, string(entries[0].Response.Body))
+		require.Empty(t, entries[0].Response.Header.Get("Content-Encoding"))
+	})
+
+	t.Run("Replays match the recording", func(t *testing.T) {
+		replayer, err := replay.New(srv.URL)
+		require.NoError(t, err)
+
+		result := replayer.WithCompare(true).Replay(context.Background(), entries[0])
+		require.True(t, result.Compared)
+		require.Empty(t, result.Diffs)
+	})
+}
@@ -0,0 +1,57 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarios(t *testing.T) {
+	srv := Start(t, Routes(
+		On("POST /orders").Status(http.StatusCreated).Scenario("order", "started", "created"),
+		On("GET /orders/1").Body(`{"id": 1}`).Scenario("order", "created", ""),
+		On("GET /orders/1").Status(http.StatusNotFound).Body(`{"error": "not found"}`),
+	))
+
+	getOrder := func(t *testing.T) (int, string) {
+		resp, err := http.Get(srv.URL + "/orders/1")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("Routes answer depending on the state", func(t *testing.T) {
+		status, body := getOrder(t)
+		require.Equal(t, http.StatusNotFound, status)
+		require.Equal(t, `{"error": "not found"}`, body)
+
+		resp, err := http.Post(srv.URL+"/orders", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		status, body = getOrder(t)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, `{"id": 1}`, body)
+	})
+
+	t.Run("Routes wait for their required state", func(t *testing.T) {
+		resp, err := http.Post(srv.URL+"/orders", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Reset starts the flow over", func(t *testing.T) {
+		srv.ResetScenarios()
+
+		status, _ := getOrder(t)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
@@ -0,0 +1,239 @@
+// Package quickresttest runs quickrest mocks inside Go tests.
+//
+//	srv := quickresttest.Start(t, quickresttest.Routes(
+//		quickresttest.On("GET /users/{id}").Status(200).JSON(user),
+//	))
+//
+//	resp, err := http.Get(srv.URL + "/users/1")
+//	...
+//	srv.AssertCalled(t, "GET /users/{id}")
+package quickresttest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kaato137/quickrest/internal"
+	"github.com/kaato137/quickrest/internal/conf"
+)
+
+// Server is a running mock. The embedded httptest.Server provides URL,
+// Client and Close.
+type Server struct {
+	*httptest.Server
+
+	mock *internal.Server
+
+	mutex    sync.Mutex
+	requests []Request
+}
+
+// Request is a request received by the mock.
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+
+	// Status is the status code the mock answered with.
+	Status int
+}
+
+// New starts a mock for the configuration. Logs are discarded.
+func New(cfg *Config) (*Server, error) {
+	return newServer(cfg, internal.NewLoggerTo(io.Discard))
+}
+
+// Start starts a mock that logs to t and is closed when the test ends.
+// It fails the test if the configuration is invalid.
+func Start(t testing.TB, cfg *Config) *Server {
+	t.Helper()
+
+	s, err := newServer(cfg, internal.NewLoggerTo(testWriter{t}))
+	if err != nil {
+		t.Fatalf("quickresttest: %v", err)
+	}
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newServer(cfg *Config, logger internal.Logger) (*Server, error) {
+	if err := conf.Prepare(cfg); err != nil {
+		return nil, fmt.Errorf("prepare config: %w", err)
+	}
+
+	mock, err := internal.NewServerWithLogger(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("create server: %w", err)
+	}
+
+	s := &Server{mock: mock}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s, nil
+}
+
+func (s *Server) Close() {
+	s.Server.Close()
+	s.mock.Close()
+}
+
+func (s *Server) serveHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	sw := &statusWriter{ResponseWriter: rw, status: http.StatusOK}
+	s.mock.ServeHTTP(sw, r)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		URL:    r.URL,
+		Header: r.Header.Clone(),
+		Body:   body,
+		Status: sw.status,
+	})
+}
+
+// Requests returns the received requests matching a pattern such as
+// "POST /users/{id}", in the order they arrived. An empty pattern matches
+// every request.
+func (s *Server) Requests(pattern string) []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if pattern == "" {
+		return append([]Request(nil), s.requests...)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+
+	var matched []Request
+	for _, req := range s.requests {
+		if _, p := mux.Handler(&http.Request{Method: req.Method, URL: req.URL, Host: s.Listener.Addr().String()}); p == pattern {
+			matched = append(matched, req)
+		}
+	}
+
+	return matched
+}
+
+// Reset forgets the received requests.
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = nil
+}
+
+// ResetScenarios moves every scenario back to its initial state.
+func (s *Server) ResetScenarios() {
+	s.mock.ResetScenarios()
+}
+
+// AssertCalled fails the test unless a request matched the pattern.
+func (s *Server) AssertCalled(t testing.TB, pattern string) {
+	t.Helper()
+
+	if len(s.Requests(pattern)) == 0 {
+		t.Errorf("expected a request to %q, got none%s", pattern, s.describeRequests())
+	}
+}
+
+// AssertCalledTimes fails the test unless exactly n requests matched the
+// pattern.
+func (s *Server) AssertCalledTimes(t testing.TB, pattern string, n int) {
+	t.Helper()
+
+	if got := len(s.Requests(pattern)); got != n {
+		t.Errorf("expected %d requests to %q, got %d%s", n, pattern, got, s.describeRequests())
+	}
+}
+
+// AssertNotCalled fails the test if a request matched the pattern.
+func (s *Server) AssertNotCalled(t testing.TB, pattern string) {
+	t.Helper()
+
+	if got := len(s.Requests(pattern)); got != 0 {
+		t.Errorf("expected no requests to %q, got %d", pattern, got)
+	}
+}
+
+func (s *Server) describeRequests() string {
+	requests := s.Requests("")
+	if len(requests) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("; received:")
+	for _, req := range requests {
+		fmt.Fprintf(&b, "\n\t%s %s -> %d", req.Method, req.URL.RequestURI(), req.Status)
+	}
+
+	return b.String()
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	w.status = http.StatusSwitchingProtocols
+
+	return h.Hijack()
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type testWriter struct {
+	t testing.TB
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
@@ -0,0 +1,171 @@
+package quickresttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	t.Run("Serves routes built in code", func(t *testing.T) {
+		srv := Start(t, Routes(
+			On("GET /users/{id}").Status(http.StatusCreated).JSON(map[string]string{"id": "{id}"}),
+		))
+
+		resp, err := http.Get(srv.URL + "/users/7")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		require.JSONEq(t, `{"id": "7"}`, string(body))
+	})
+
+	t.Run("Records received requests", func(t *testing.T) {
+		srv := Start(t, Routes(
+			On("POST /orders").Status(http.StatusAccepted),
+			On("GET /orders/{id}"),
+		))
+
+		resp, err := http.Post(srv.URL+"/orders", "application/json", strings.NewReader(`{"qty":2}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		requests := srv.Requests("POST /orders")
+		require.Len(t, requests, 1)
+		require.Equal(t, `{"qty":2}`, string(requests[0].Body))
+		require.Equal(t, http.StatusAccepted, requests[0].Status)
+
+		srv.AssertCalledTimes(t, "POST /orders", 1)
+		srv.AssertNotCalled(t, "GET /orders/{id}")
+
+		srv.Reset()
+		srv.AssertNotCalled(t, "POST /orders")
+	})
+
+	t.Run("Rejects invalid configurations", func(t *testing.T) {
+		_, err := New(&Config{Routes: []Route{{Path: "/x", Type: "unknown"}}})
+		require.Error(t, err)
+	})
+
+	t.Run("Rejects wildcards named like script globals", func(t *testing.T) {
+		_, err := New(Routes(On("GET /users/{uuid}").BodyJS(`({id: uuid})`)))
+		require.ErrorContains(t, err, `wildcard name is taken by a script global: "uuid"`)
+	})
+}
+
+func TestAuth(t *testing.T) {
+	srv := Start(t, Routes(
+		On("GET /basic").Auth(&AuthConfig{Type: "basic", Users: map[string]string{"alice": "secret"}}),
+		On("GET /me").
+			Auth(&AuthConfig{Type: "bearer", JWT: &JWTConfig{Secret: "key", Audience: "api", Scopes: []string{"read"}}}).
+			Template(`{{ .Claims.sub }}`),
+	))
+
+	get := func(path string, auth func(*http.Request)) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		auth(req)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(body)
+	}
+
+	bearer := func(claims jwt.MapClaims) func(*http.Request) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("key"))
+		require.NoError(t, err)
+
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	t.Run("Basic auth asks for credentials", func(t *testing.T) {
+		resp, _ := get("/basic", func(*http.Request) {})
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Equal(t, `Basic realm="quickrest"`, resp.Header.Get("WWW-Authenticate"))
+
+		resp, _ = get("/basic", func(r *http.Request) { r.SetBasicAuth("alice", "secret") })
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Valid tokens expose their claims", func(t *testing.T) {
+		resp, body := get("/me", bearer(jwt.MapClaims{"sub": "bob", "aud": "api", "scope": "read write", "exp": time.Now().Add(time.Hour).Unix()}))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "bob", body)
+	})
+
+	t.Run("Expired tokens are rejected", func(t *testing.T) {
+		resp, _ := get("/me", bearer(jwt.MapClaims{"aud": "api", "scope": "read", "exp": time.Now().Add(-time.Hour).Unix()}))
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Contains(t, resp.Header.Get("WWW-Authenticate"), `error="invalid_token"`)
+	})
+
+	t.Run("Tokens without an expiry are rejected", func(t *testing.T) {
+		resp, _ := get("/me", bearer(jwt.MapClaims{"sub": "bob", "aud": "api", "scope": "read"}))
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Contains(t, resp.Header.Get("WWW-Authenticate"), `error="invalid_token"`)
+	})
+
+	t.Run("Missing scopes are forbidden", func(t *testing.T) {
+		resp, _ := get("/me", bearer(jwt.MapClaims{"aud": "api", "scope": "write", "exp": time.Now().Add(time.Hour).Unix()}))
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		require.Contains(t, resp.Header.Get("WWW-Authenticate"), `error="insufficient_scope"`)
+	})
+}
+
+// TestConcurrentScripts is meant for -race. The requests must run in
+// parallel for the detector to see a shared VM, even on a single CPU.
+func TestConcurrentScripts(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(max(runtime.GOMAXPROCS(0), 4)))
+
+	srv := Start(t, Routes(
+		On("GET /users/{id}").
+			Auth(&AuthConfig{Type: "bearer", JWT: &JWTConfig{Secret: "key"}}).
+			BodyJS(`var sub = claims.sub; for (var i = 0; i < 1000; i++) {} ({id: id, sub: sub, name: faker.name()})`),
+	))
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": fmt.Sprint("user-", i), "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte("key"))
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprint(srv.URL, "/users/", i), nil)
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp, err := http.DefaultClient.Do(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			var body struct{ ID, Sub string }
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, fmt.Sprint(i), body.ID)
+			assert.Equal(t, fmt.Sprint("user-", i), body.Sub)
+		}()
+	}
+	wg.Wait()
+}
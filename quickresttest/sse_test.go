@@ -0,0 +1,90 @@
+package quickresttest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSE(t *testing.T) {
+	srv := Start(t, &Config{Routes: []Route{
+		{
+			Path: "GET /notifications/{user_id}",
+			Type: "sse",
+			Events: []EventConfig{
+				{ID: "{user_id}-1", Event: "notification-{user_id}", Data: `{"user_id": "{user_id}"}`},
+				{Data: "line one\nline two"},
+			},
+		},
+		{
+			Path:     "GET /ticks",
+			Type:     "sse",
+			EventsJS: `for (var i = 0; i < 2; i++) { emit({"tick": i}, {"id": String(i), "event": "tick", "retry": 500}); }`,
+		},
+		{
+			Path:   "GET /spin",
+			Type:   "sse",
+			Loop:   true,
+			Events: []EventConfig{{Data: "tick"}},
+		},
+		{
+			Path:     "GET /spin-js",
+			Type:     "sse",
+			Loop:     true,
+			EventsJS: `emit("tick")`,
+		},
+	}})
+
+	stream := func(t *testing.T, path string) string {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(body)
+	}
+
+	t.Run("Streams the configured events", func(t *testing.T) {
+		require.Equal(t,
+			"id: 42-1\nevent: notification-42\ndata: {\"user_id\": \"42\"}\n\n"+
+				"data: line one\ndata: line two\n\n",
+			stream(t, "/notifications/42"))
+	})
+
+	t.Run("Streams the events a script emits", func(t *testing.T) {
+		require.Equal(t,
+			"id: 0\nevent: tick\nretry: 500\ndata: {\"tick\":0}\n\n"+
+				"id: 1\nevent: tick\nretry: 500\ndata: {\"tick\":1}\n\n",
+			stream(t, "/ticks"))
+	})
+
+	t.Run("Loops without delays are paced", func(t *testing.T) {
+		for _, path := range []string{"/spin", "/spin-js"} {
+			ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			ticks := strings.Count(string(body), "data: tick\n")
+			require.GreaterOrEqual(t, ticks, 2, path)
+			require.LessOrEqual(t, ticks, 5, path)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredBodies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quickrest.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+routes:
+- path: GET /users/{id:int}
+  json:
+    id: "{id}"
+    tags: [admin, "user {id}"]
+    active: true
+- path: GET /users/{id:int}.xml
+  xml:
+    user: {"@id": "{id}", name: Ann}
+- path: GET /users/{id:int}.yaml
+  yaml: {id: "{id}"}
+`), 0o644))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	srv := Start(t, cfg)
+
+	get := func(t *testing.T, path string) (*http.Response, string) {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(body)
+	}
+
+	t.Run("JSON with wildcards in strings", func(t *testing.T) {
+		resp, body := get(t, "/users/7")
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		require.JSONEq(t, `{"id": "7", "tags": ["admin", "user 7"], "active": true}`, body)
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		resp, body := get(t, "/users/7.xml")
+		require.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+		require.Equal(t, `<user id="7"><name>Ann</name></user>`, body)
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		resp, body := get(t, "/users/7.yaml")
+		require.Equal(t, "application/yaml", resp.Header.Get("Content-Type"))
+		require.Equal(t, "id: \"7\"\n", body)
+	})
+
+	t.Run("Invalid bodies are rejected at load time", func(t *testing.T) {
+		route := On("GET /").Route()
+		route.XML = map[string]any{"a": 1, "b": 2}
+
+		_, err := New(&Config{Routes: []Route{route}})
+		require.ErrorContains(t, err, "xml body must be a map with a single root element")
+	})
+}
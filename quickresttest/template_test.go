@@ -0,0 +1,97 @@
+package quickresttest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoTemplates(t *testing.T) {
+	srv := Start(t, Routes(
+		On("POST /users/{id}/notifications").Template(
+			`[{{ range $i, $n := seq 3 }}{{ if $i }},{{ end }}{"id": {{ add $n $.Params.id }}, "to": "{{ upper $.JSON.name }}"}{{ end }}]`),
+		On("GET /search").Template(`{"q": {{ json (default "all" .Query.q) }}, "agent": {{ json .Headers.Agent }}}`),
+		On("POST /orders").Status(http.StatusCreated).Scenario("order", "started", "created"),
+		On("GET /orders/state").Template(`{"order": "{{ .State.order }}"}`),
+		On("GET /math/{id}").Template(
+			`{"next": {{ add .Params.id 1 }}, "big": {{ mul .Params.id 1000000000 }}, "half": {{ add .Params.id 0.5 }}}`),
+		On("GET /seq/{n}").Template(`{{ len (seq (int .Params.n)) }}`),
+	))
+
+	t.Run("Renders the request into the body", func(t *testing.T) {
+		resp, err := http.Post(srv.URL+"/users/10/notifications", "application/json", strings.NewReader(`{"name": "bob"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.JSONEq(t, `[{"id": 10, "to": "BOB"}, {"id": 11, "to": "BOB"}, {"id": 12, "to": "BOB"}]`, string(body))
+	})
+
+	t.Run("Reads the query and headers", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/search", nil)
+		require.NoError(t, err)
+		req.Header.Set("Agent", "tests")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"q": "all", "agent": "tests"}`, string(body))
+	})
+
+	t.Run("Reads the scenario states", func(t *testing.T) {
+		get := func(t *testing.T) string {
+			resp, err := http.Get(srv.URL + "/orders/state")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			return string(body)
+		}
+
+		require.JSONEq(t, `{"order": "started"}`, get(t))
+
+		resp, err := http.Post(srv.URL+"/orders", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		require.JSONEq(t, `{"order": "created"}`, get(t))
+	})
+	t.Run("Keeps integer arithmetic for integers", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/math/7")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"next": 8, "big": 7000000000, "half": 7.5}`, string(body))
+		require.Contains(t, string(body), `"big": 7000000000,`)
+	})
+
+	t.Run("Caps the length of seq", func(t *testing.T) {
+		get := func(t *testing.T, n string) string {
+			resp, err := http.Get(srv.URL + "/seq/" + n)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			return string(body)
+		}
+
+		require.Equal(t, "10000", get(t, "10000"))
+		require.Empty(t, get(t, "10001"))
+	})
+}
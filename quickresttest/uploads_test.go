@@ -0,0 +1,90 @@
+package quickresttest
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploads(t *testing.T) {
+	dir := t.TempDir()
+
+	upload := On("POST /upload").Template(`{{ .Form.title }}:{{ range .Files }}{{ .Name }} {{ .Size }} {{ .ContentType }}{{ end }}`).Route()
+	upload.Record = true
+
+	cfg := Routes(
+		On("POST /plain").Body("ok"),
+		On("POST /script").BodyJS(`({title: form.title, files: files.map(function(f) { return f.name + " " + f.sha256; })})`),
+	)
+	cfg.Routes = append(cfg.Routes, upload)
+	cfg.RecordDir = filepath.Join(dir, "records")
+	cfg.UploadDir = filepath.Join(dir, "uploads")
+
+	srv := Start(t, cfg)
+
+	post := func(t *testing.T, path string, content []byte) *http.Response {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		require.NoError(t, w.WriteField("title", "Holiday"))
+		part, err := w.CreateFormFile("photo", "beach.png")
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		resp, err := http.Post(srv.URL+path, w.FormDataContentType(), &buf)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	read := func(t *testing.T, resp *http.Response) string {
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(body)
+	}
+
+	t.Run("Templates see fields and files", func(t *testing.T) {
+		require.Equal(t, "Holiday:beach.png 5 application/octet-stream", read(t, post(t, "/upload", []byte("hello"))))
+	})
+
+	t.Run("Scripts see fields and files", func(t *testing.T) {
+		require.JSONEq(t, `{"title": "Holiday", "files": ["beach.png 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"]}`, read(t, post(t, "/script", []byte("hello"))))
+	})
+
+	t.Run("Uploads are recorded and saved", func(t *testing.T) {
+		logs, err := filepath.Glob(filepath.Join(dir, "records", "*.log"))
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+
+		log, err := os.ReadFile(logs[0])
+		require.NoError(t, err)
+
+		saved := filepath.Join(dir, "uploads", "2cf24dba5fb0a30e-beach.png")
+		require.Contains(t, string(log), "field title=\"Holiday\"\n")
+		require.Contains(t, string(log), `file photo name="beach.png" size=5 type="application/octet-stream" sha256=2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824 saved="`+saved+`"`)
+
+		data, err := os.ReadFile(saved)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(data))
+	})
+
+	t.Run("Bodies over the limit are rejected", func(t *testing.T) {
+		large := bytes.Repeat([]byte("a"), 33<<20)
+
+		resp := post(t, "/upload", large)
+		resp.Body.Close()
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+		require.Equal(t, "ok", read(t, post(t, "/plain", large)))
+	})
+}
@@ -0,0 +1,98 @@
+package quickresttest
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocket(t *testing.T) {
+	srv := Start(t, &Config{Routes: []Route{{
+		Path: "GET /prices/{symbol}",
+		Type: "websocket",
+		WebSocket: WebSocketConfig{
+			OnConnect: []WSMessageConfig{{Send: `{"type": "hello", "symbol": "{symbol}"}`}},
+			Replies: []WSReplyConfig{
+				{Match: "^ping$", WSMessageConfig: WSMessageConfig{Send: "pong"}},
+				{
+					MatchJSON:       map[string]any{"type": "subscribe"},
+					WSMessageConfig: WSMessageConfig{SendJS: `({"type": "subscribed", "channel": message.channel, "symbol": symbol})`},
+				},
+				{
+					MatchJSON:       map[string]any{"type": "order", "order": map[string]any{"side": "buy", "qty": 2}},
+					WSMessageConfig: WSMessageConfig{Send: "filled"},
+				},
+				{
+					Match:           "^bye$",
+					WSMessageConfig: WSMessageConfig{Send: "bye"},
+					Close:           &WSCloseConfig{Code: 4000, Reason: "done"},
+				},
+			},
+		},
+	}, {
+		Path: "GET /silent",
+		Type: "websocket",
+		WebSocket: WebSocketConfig{
+			Close: &WSCloseConfig{After: 10 * time.Millisecond},
+		},
+	}}})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/prices/ACME", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	read := func(t *testing.T) string {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("Greets on connect", func(t *testing.T) {
+		require.JSONEq(t, `{"type": "hello", "symbol": "ACME"}`, read(t))
+	})
+
+	t.Run("Replies to matching messages", func(t *testing.T) {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+		require.Equal(t, "pong", read(t))
+	})
+
+	t.Run("Replies to matching JSON with a script", func(t *testing.T) {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "subscribe", "channel": "trades"}`)))
+		require.JSONEq(t, `{"type": "subscribed", "channel": "trades", "symbol": "ACME"}`, read(t))
+	})
+
+	t.Run("Matches nested JSON by value", func(t *testing.T) {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "order", "order": {"side": "sell", "qty": 2}}`)))
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "order", "order": {"side": "buy", "qty": 2.0, "price": 3}}`)))
+		require.Equal(t, "filled", read(t))
+	})
+
+	t.Run("Closes after the closing reply", func(t *testing.T) {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("bye")))
+		require.Equal(t, "bye", read(t))
+
+		_, _, err := conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		require.Equal(t, 4000, closeErr.Code)
+		require.Equal(t, "done", closeErr.Text)
+	})
+
+	t.Run("Drops clients that do not answer the close", func(t *testing.T) {
+		silent, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/silent", nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { silent.Close() })
+
+		// Reading the raw connection skips the close answer the client
+		// would otherwise send.
+		raw := silent.UnderlyingConn()
+		require.NoError(t, raw.SetReadDeadline(time.Now().Add(5*time.Second)))
+		_, err = io.Copy(io.Discard, raw)
+		require.NoError(t, err)
+	})
+}